@@ -0,0 +1,14 @@
+package generate
+
+// ParamsError is returned if the action inputs are invalid.
+type ParamsError struct {
+	Err error
+}
+
+func (e *ParamsError) Error() string {
+	return "failed to load parameters: " + e.Err.Error()
+}
+
+func (e *ParamsError) Unwrap() error {
+	return e.Err
+}
@@ -5,39 +5,26 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/snapfi/semver-action/internal/versioning"
 	"github.com/snapfi/semver-action/pkg/actions"
+	"github.com/snapfi/semver-action/pkg/conventional"
 
 	"github.com/blang/semver/v4"
 )
 
-var (
-	// nolint
-	commitShaRegex = regexp.MustCompile(`\b[0-9a-f]{5,40}\b`)
-	// nolint
-	validBumpStrategies = []string{"auto", "major", "minor", "patch"}
-)
-
-// Params contains semver generate command parameters.
-type Params struct {
-	CommitSha       string
-	RepoDir         string
-	Bump            string
-	BaseVersion     *semver.Version
-	Prefix          string
-	PrereleaseID    string
-	ForcePrerelease bool
-	BranchName      string
-	Debug           bool
-}
+// nolint
+var commitShaRegex = regexp.MustCompile(`\b[0-9a-f]{5,40}\b`)
 
 // LoadParams loads semver generate config params.
-func LoadParams() (Params, error) {
+func LoadParams() (versioning.Params, error) {
 	var commitSha string
 
 	if commitShaStr := os.Getenv("GITHUB_SHA"); commitShaStr != "" {
 		if !commitShaRegex.MatchString(commitShaStr) {
-			return Params{}, fmt.Errorf("invalid commit-sha format: %s", commitShaStr)
+			return versioning.Params{}, fmt.Errorf("invalid commit-sha format: %s", commitShaStr)
 		}
 
 		commitSha = commitShaStr
@@ -52,19 +39,33 @@ func LoadParams() (Params, error) {
 	var bump = "auto"
 
 	if bumpStr := actions.GetInput("bump"); bumpStr != "" {
-		if !stringInSlice(bumpStr, validBumpStrategies) {
-			return Params{}, fmt.Errorf("invalid bump value: %s", bumpStr)
-		}
-
 		bump = bumpStr
 	}
 
+	var mode = "generate"
+
+	if modeStr := actions.GetInput("mode"); modeStr != "" {
+		mode = modeStr
+	}
+
+	var scheme = "semver"
+
+	if schemeStr := actions.GetInput("scheme"); schemeStr != "" {
+		scheme = schemeStr
+	}
+
+	var calverFormat = "YYYY.MM.MICRO"
+
+	if calverFormatStr := actions.GetInput("calver_format"); calverFormatStr != "" {
+		calverFormat = calverFormatStr
+	}
+
 	var debug bool
 
 	if debugStr := actions.GetInput("debug"); debugStr != "" {
 		parsed, err := strconv.ParseBool(debugStr)
 		if err != nil {
-			return Params{}, fmt.Errorf("invalid debug argument: %s", debugStr)
+			return versioning.Params{}, fmt.Errorf("invalid debug argument: %s", debugStr)
 		}
 
 		debug = parsed
@@ -84,7 +85,7 @@ func LoadParams() (Params, error) {
 
 		parsed, err := semver.Parse(baseVersionStr)
 		if err != nil {
-			return Params{}, fmt.Errorf("invalid base_version format: %s", baseVersionStr)
+			return versioning.Params{}, fmt.Errorf("invalid base_version format: %s", baseVersionStr)
 		}
 
 		baseVersion = &parsed
@@ -102,58 +103,216 @@ func LoadParams() (Params, error) {
 		prereleaseID = prereleaseIDStr
 	}
 
+	var prereleaseChannels []string
+
+	if prereleaseChannelsStr := actions.GetInput("prerelease_channels"); prereleaseChannelsStr != "" {
+		for _, channel := range strings.Split(prereleaseChannelsStr, ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				prereleaseChannels = append(prereleaseChannels, channel)
+			}
+		}
+	}
+
+	var promoteTo = actions.GetInput("promote_to")
+
+	labels, err := actions.GetLabels()
+	if err != nil {
+		return versioning.Params{}, fmt.Errorf("failed to get pull request labels: %w", err)
+	}
+
+	for _, label := range labels {
+		if channel := strings.TrimPrefix(label, "promote:"); channel != label && promoteTo == "" {
+			promoteTo = strings.TrimSpace(channel)
+		}
+	}
+
 	var forcePrerelease bool
 
 	if forcePrereleaseStr := actions.GetInput("force_prerelease"); forcePrereleaseStr != "" {
 		parsed, err := strconv.ParseBool(forcePrereleaseStr)
 		if err != nil {
-			return Params{}, fmt.Errorf("invalid force_prerelease argument: %s", forcePrereleaseStr)
+			return versioning.Params{}, fmt.Errorf("invalid force_prerelease argument: %s", forcePrereleaseStr)
 		}
 
 		forcePrerelease = parsed
 	}
 
-	return Params{
-		CommitSha:       commitSha,
-		RepoDir:         repoDir,
-		Bump:            bump,
-		BaseVersion:     baseVersion,
-		Prefix:          prefix,
-		PrereleaseID:    prereleaseID,
-		ForcePrerelease: forcePrerelease,
-		BranchName:      branchName,
-		Debug:           debug,
-	}, nil
-}
+	var initialDevelopment bool
 
-func stringInSlice(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
+	if initialDevelopmentStr := actions.GetInput("initial_development"); initialDevelopmentStr != "" {
+		parsed, err := strconv.ParseBool(initialDevelopmentStr)
+		if err != nil {
+			return versioning.Params{}, fmt.Errorf("invalid initial_development argument: %s", initialDevelopmentStr)
 		}
+
+		initialDevelopment = parsed
 	}
 
-	return false
-}
+	var changeDetectors []string
+
+	if changeDetectorsStr := actions.GetInput("change_detectors"); changeDetectorsStr != "" {
+		for _, name := range strings.Split(changeDetectorsStr, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				changeDetectors = append(changeDetectors, name)
+			}
+		}
+	}
+
+	var changeDetectorMode = "floor"
+
+	if changeDetectorModeStr := actions.GetInput("change_detector_mode"); changeDetectorModeStr != "" {
+		changeDetectorMode = changeDetectorModeStr
+	}
+
+	var retracted map[string]string
+
+	if retractedVersionsStr := actions.GetInput("retracted_versions"); retractedVersionsStr != "" {
+		retracted = make(map[string]string)
+
+		for _, tag := range strings.Split(retractedVersionsStr, ",") {
+			if tag = strings.TrimSpace(tag); tag == "" {
+				continue
+			}
+
+			if !strings.HasPrefix(tag, prefix) {
+				tag = prefix + tag
+			}
+
+			retracted[tag] = "listed in retracted_versions"
+		}
+	}
+
+	var convention = conventional.Default()
+
+	if commitTypesStr := actions.GetInput("commit_types"); commitTypesStr != "" {
+		convention.Types = nil
+
+		for _, commitType := range strings.Split(commitTypesStr, ",") {
+			if commitType = strings.TrimSpace(commitType); commitType != "" {
+				convention.Types = append(convention.Types, commitType)
+			}
+		}
+	}
+
+	if commitScopeRequiredStr := actions.GetInput("commit_scope_required"); commitScopeRequiredStr != "" {
+		parsed, err := strconv.ParseBool(commitScopeRequiredStr)
+		if err != nil {
+			return versioning.Params{}, fmt.Errorf("invalid commit_scope_required argument: %s", commitScopeRequiredStr)
+		}
+
+		convention.RequireScope = parsed
+	}
+
+	if commitHeaderMaxLengthStr := actions.GetInput("commit_header_max_length"); commitHeaderMaxLengthStr != "" {
+		parsed, err := strconv.Atoi(commitHeaderMaxLengthStr)
+		if err != nil || parsed < 0 {
+			return versioning.Params{}, fmt.Errorf("invalid commit_header_max_length argument: %s", commitHeaderMaxLengthStr)
+		}
+
+		convention.MaxHeaderLength = parsed
+	}
+
+	var goModule bool
+
+	if goModuleStr := actions.GetInput("go_module"); goModuleStr != "" {
+		parsed, err := strconv.ParseBool(goModuleStr)
+		if err != nil {
+			return versioning.Params{}, fmt.Errorf("invalid go_module argument: %s", goModuleStr)
+		}
+
+		goModule = parsed
+	}
+
+	var goModuleRewrite bool
+
+	if goModuleRewriteStr := actions.GetInput("go_module_rewrite"); goModuleRewriteStr != "" {
+		parsed, err := strconv.ParseBool(goModuleRewriteStr)
+		if err != nil {
+			return versioning.Params{}, fmt.Errorf("invalid go_module_rewrite argument: %s", goModuleRewriteStr)
+		}
+
+		goModuleRewrite = parsed
+	}
+
+	var gitTimeout = 2 * time.Minute
+
+	if gitTimeoutStr := actions.GetInput("git_timeout"); gitTimeoutStr != "" {
+		parsed, err := time.ParseDuration(gitTimeoutStr)
+		if err != nil {
+			return versioning.Params{}, fmt.Errorf("invalid git_timeout argument: %s", gitTimeoutStr)
+		}
+
+		gitTimeout = parsed
+	}
+
+	var gitSnapshot bool
+
+	if gitSnapshotStr := actions.GetInput("git_snapshot"); gitSnapshotStr != "" {
+		parsed, err := strconv.ParseBool(gitSnapshotStr)
+		if err != nil {
+			return versioning.Params{}, fmt.Errorf("invalid git_snapshot argument: %s", gitSnapshotStr)
+		}
+
+		gitSnapshot = parsed
+	}
+
+	var metadata = "none"
+
+	if metadataStr := actions.GetInput("metadata"); metadataStr != "" {
+		metadata = metadataStr
+	}
+
+	var timeout = 10 * time.Minute
+
+	if timeoutStr := actions.GetInput("timeout"); timeoutStr != "" {
+		parsed, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return versioning.Params{}, fmt.Errorf("invalid timeout argument: %s", timeoutStr)
+		}
+
+		timeout = parsed
+	}
+
+	headRef, baseRef := os.Getenv("GITHUB_HEAD_REF"), os.Getenv("GITHUB_BASE_REF")
+
+	params := versioning.Params{
+		Mode:               mode,
+		HeadRef:            headRef,
+		BaseRef:            baseRef,
+		CommitSha:          commitSha,
+		RepoDir:            repoDir,
+		Bump:               bump,
+		Scheme:             scheme,
+		CalVerFormat:       calverFormat,
+		BaseVersion:        baseVersion,
+		Prefix:             prefix,
+		PrereleaseID:       prereleaseID,
+		PrereleaseChannels: prereleaseChannels,
+		PromoteTo:          promoteTo,
+		ForcePrerelease:    forcePrerelease,
+		InitialDevelopment: initialDevelopment,
+		ChangeDetectors:    changeDetectors,
+		ChangeDetectorMode: changeDetectorMode,
+		Retracted:          retracted,
+		Convention:         convention,
+		StrategyPlugin:     actions.GetInput("strategy_plugin"),
+		GitTimeout:         gitTimeout,
+		GitSnapshot:        gitSnapshot,
+		Metadata:           metadata,
+		Timeout:            timeout,
+		GoModule:           goModule,
+		GoModuleRewrite:    goModuleRewrite,
+		BranchName:         branchName,
+		Debug:              debug,
+	}
+
+	if err := params.Validate(); err != nil {
+		return versioning.Params{}, err
+	}
+
+	if mode != "generate" && (headRef == "" || baseRef == "") {
+		return versioning.Params{}, fmt.Errorf("%s mode needs a pull_request event, GITHUB_HEAD_REF or GITHUB_BASE_REF is empty", mode)
+	}
 
-func (p Params) String() string {
-	var baseVersion string
-	if p.BaseVersion != nil {
-		baseVersion = p.BaseVersion.String()
-	}
-
-	return fmt.Sprintf(
-		"commit sha: %q, bump: %q, base version: %q, prefix: %q,"+
-			" prerelease id: %q, force prerelease: %t, branch name: %q,"+
-			" repo dir: %q, debug: %t\n",
-		p.CommitSha,
-		p.Bump,
-		baseVersion,
-		p.Prefix,
-		p.PrereleaseID,
-		p.ForcePrerelease,
-		p.BranchName,
-		p.RepoDir,
-		p.Debug,
-	)
+	return params, nil
 }
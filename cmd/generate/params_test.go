@@ -2,9 +2,12 @@ package generate_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/snapfi/semver-action/cmd/generate"
+	"github.com/snapfi/semver-action/pkg/conventional"
 
 	"github.com/blang/semver/v4"
 	"github.com/stretchr/testify/assert"
@@ -45,6 +48,58 @@ func TestLoadParams_PrereleaseID_Default(t *testing.T) {
 	assert.Equal(t, "pre", params.PrereleaseID)
 }
 
+func TestLoadParams_PrereleaseChannels(t *testing.T) {
+	os.Setenv("INPUT_PRERELEASE_CHANNELS", "alpha, beta,rc")
+	defer os.Unsetenv("INPUT_PRERELEASE_CHANNELS")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"alpha", "beta", "rc"}, params.PrereleaseChannels)
+}
+
+func TestLoadParams_PromoteTo(t *testing.T) {
+	os.Setenv("INPUT_PRERELEASE_CHANNELS", "alpha,beta,rc")
+	defer os.Unsetenv("INPUT_PRERELEASE_CHANNELS")
+
+	os.Setenv("INPUT_PROMOTE_TO", "rc")
+	defer os.Unsetenv("INPUT_PROMOTE_TO")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "rc", params.PromoteTo)
+}
+
+func TestLoadParams_PromoteTo_Label(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "event.json")
+
+	err := os.WriteFile(fp, []byte(`{"pull_request":{"labels":[{"name":"promote:beta"}]}}`), 0600)
+	require.NoError(t, err)
+
+	os.Setenv("GITHUB_EVENT_PATH", fp)
+	defer os.Unsetenv("GITHUB_EVENT_PATH")
+
+	os.Setenv("INPUT_PRERELEASE_CHANNELS", "alpha,beta,rc")
+	defer os.Unsetenv("INPUT_PRERELEASE_CHANNELS")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "beta", params.PromoteTo)
+}
+
+func TestLoadParams_InvalidPromoteTo(t *testing.T) {
+	os.Setenv("INPUT_PRERELEASE_CHANNELS", "alpha,beta,rc")
+	defer os.Unsetenv("INPUT_PRERELEASE_CHANNELS")
+
+	os.Setenv("INPUT_PROMOTE_TO", "gamma")
+	defer os.Unsetenv("INPUT_PROMOTE_TO")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
 func TestLoadParams_ForcePrerelease(t *testing.T) {
 	os.Setenv("INPUT_FORCE_PRERELEASE", "true")
 	defer os.Unsetenv("INPUT_FORCE_PRERELEASE")
@@ -62,6 +117,76 @@ func TestLoadParams_ForcePrerelease_Default(t *testing.T) {
 	assert.False(t, params.ForcePrerelease)
 }
 
+func TestLoadParams_InitialDevelopment(t *testing.T) {
+	os.Setenv("INPUT_INITIAL_DEVELOPMENT", "true")
+	defer os.Unsetenv("INPUT_INITIAL_DEVELOPMENT")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.True(t, params.InitialDevelopment)
+}
+
+func TestLoadParams_InvalidInitialDevelopment(t *testing.T) {
+	os.Setenv("INPUT_INITIAL_DEVELOPMENT", "invalid")
+	defer os.Unsetenv("INPUT_INITIAL_DEVELOPMENT")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_ChangeDetectors(t *testing.T) {
+	os.Setenv("INPUT_CHANGE_DETECTORS", "go, openapi,proto")
+	defer os.Unsetenv("INPUT_CHANGE_DETECTORS")
+
+	os.Setenv("INPUT_CHANGE_DETECTOR_MODE", "override")
+	defer os.Unsetenv("INPUT_CHANGE_DETECTOR_MODE")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"go", "openapi", "proto"}, params.ChangeDetectors)
+	assert.Equal(t, "override", params.ChangeDetectorMode)
+}
+
+func TestLoadParams_InvalidChangeDetectors(t *testing.T) {
+	os.Setenv("INPUT_CHANGE_DETECTORS", "go,invalid")
+	defer os.Unsetenv("INPUT_CHANGE_DETECTORS")
+
+	_, err := generate.LoadParams()
+	assert.EqualError(t, err, "invalid change_detectors value: invalid")
+}
+
+func TestLoadParams_InvalidChangeDetectorMode(t *testing.T) {
+	os.Setenv("INPUT_CHANGE_DETECTOR_MODE", "invalid")
+	defer os.Unsetenv("INPUT_CHANGE_DETECTOR_MODE")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_GoModule(t *testing.T) {
+	os.Setenv("INPUT_GO_MODULE", "true")
+	defer os.Unsetenv("INPUT_GO_MODULE")
+
+	os.Setenv("INPUT_GO_MODULE_REWRITE", "true")
+	defer os.Unsetenv("INPUT_GO_MODULE_REWRITE")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.True(t, params.GoModule)
+	assert.True(t, params.GoModuleRewrite)
+}
+
+func TestLoadParams_InvalidGoModule(t *testing.T) {
+	os.Setenv("INPUT_GO_MODULE", "invalid")
+	defer os.Unsetenv("INPUT_GO_MODULE")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
 func TestLoadParams_BranchName(t *testing.T) {
 	os.Setenv("INPUT_BRANCH_NAME", "master")
 	defer os.Unsetenv("INPUT_BRANCH_NAME")
@@ -116,11 +241,14 @@ func TestLoadParams_RepoDir_Default(t *testing.T) {
 
 func TestLoadParams_Bump(t *testing.T) {
 	tests := map[string]string{
-		"auto":  "auto",
-		"major": "major",
-		"minor": "minor",
-		"patch": "patch",
-		"empty": "auto",
+		"auto":     "auto",
+		"major":    "major",
+		"minor":    "minor",
+		"patch":    "patch",
+		"promote":  "promote",
+		"finalize": "finalize",
+		"graduate": "graduate",
+		"empty":    "auto",
 	}
 
 	for name, value := range tests {
@@ -144,6 +272,44 @@ func TestLoadParams_InvalidBump(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestLoadParams_Scheme(t *testing.T) {
+	os.Setenv("INPUT_SCHEME", "calver")
+	defer os.Unsetenv("INPUT_SCHEME")
+
+	os.Setenv("INPUT_CALVER_FORMAT", "YY.0M.MICRO")
+	defer os.Unsetenv("INPUT_CALVER_FORMAT")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "calver", params.Scheme)
+	assert.Equal(t, "YY.0M.MICRO", params.CalVerFormat)
+}
+
+func TestLoadParams_Scheme_Default(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "semver", params.Scheme)
+	assert.Equal(t, "YYYY.MM.MICRO", params.CalVerFormat)
+}
+
+func TestLoadParams_InvalidScheme(t *testing.T) {
+	os.Setenv("INPUT_SCHEME", "invalid")
+	defer os.Unsetenv("INPUT_SCHEME")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_InvalidCalVerFormat(t *testing.T) {
+	os.Setenv("INPUT_CALVER_FORMAT", "YYYY.QQ")
+	defer os.Unsetenv("INPUT_CALVER_FORMAT")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
 func TestLoadParams_BaseVersion(t *testing.T) {
 	os.Setenv("INPUT_BASE_VERSION", "1.2.3")
 	defer os.Unsetenv("INPUT_BASE_VERSION")
@@ -163,3 +329,182 @@ func TestLoadParams_InvalidBaseVersion(t *testing.T) {
 	_, err := generate.LoadParams()
 	require.Error(t, err)
 }
+
+func TestLoadParams_Timeouts(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2*time.Minute, params.GitTimeout)
+	assert.Equal(t, 10*time.Minute, params.Timeout)
+
+	os.Setenv("INPUT_GIT_TIMEOUT", "30s")
+	defer os.Unsetenv("INPUT_GIT_TIMEOUT")
+
+	os.Setenv("INPUT_TIMEOUT", "0")
+	defer os.Unsetenv("INPUT_TIMEOUT")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, 30*time.Second, params.GitTimeout)
+	assert.Equal(t, time.Duration(0), params.Timeout)
+}
+
+func TestLoadParams_InvalidTimeout(t *testing.T) {
+	os.Setenv("INPUT_GIT_TIMEOUT", "forever")
+	defer os.Unsetenv("INPUT_GIT_TIMEOUT")
+
+	_, err := generate.LoadParams()
+	assert.EqualError(t, err, "invalid git_timeout argument: forever")
+}
+
+func TestLoadParams_GitSnapshot(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.False(t, params.GitSnapshot)
+
+	os.Setenv("INPUT_GIT_SNAPSHOT", "true")
+	defer os.Unsetenv("INPUT_GIT_SNAPSHOT")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.True(t, params.GitSnapshot)
+}
+
+func TestLoadParams_Metadata(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "none", params.Metadata)
+
+	os.Setenv("INPUT_METADATA", "notes")
+	defer os.Unsetenv("INPUT_METADATA")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "notes", params.Metadata)
+}
+
+func TestLoadParams_InvalidMetadata(t *testing.T) {
+	os.Setenv("INPUT_METADATA", "tag")
+	defer os.Unsetenv("INPUT_METADATA")
+
+	_, err := generate.LoadParams()
+	assert.EqualError(t, err, "invalid metadata value: tag")
+}
+
+func TestLoadParams_RetractedVersions(t *testing.T) {
+	os.Setenv("INPUT_RETRACTED_VERSIONS", "v3.2.0, 3.2.1,")
+	defer os.Unsetenv("INPUT_RETRACTED_VERSIONS")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"v3.2.0": "listed in retracted_versions",
+		"v3.2.1": "listed in retracted_versions",
+	}, params.Retracted)
+}
+
+func TestLoadParams_Mode(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "generate", params.Mode)
+
+	os.Setenv("INPUT_MODE", "validate")
+	defer os.Unsetenv("INPUT_MODE")
+
+	os.Setenv("GITHUB_HEAD_REF", "feature/some")
+	defer os.Unsetenv("GITHUB_HEAD_REF")
+
+	os.Setenv("GITHUB_BASE_REF", "main")
+	defer os.Unsetenv("GITHUB_BASE_REF")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "validate", params.Mode)
+	assert.Equal(t, "feature/some", params.HeadRef)
+	assert.Equal(t, "main", params.BaseRef)
+}
+
+func TestLoadParams_InvalidMode(t *testing.T) {
+	os.Setenv("INPUT_MODE", "check")
+	defer os.Unsetenv("INPUT_MODE")
+
+	_, err := generate.LoadParams()
+	assert.EqualError(t, err, "invalid mode value: check")
+}
+
+func TestLoadParams_ValidateWithoutPullRequest(t *testing.T) {
+	os.Setenv("INPUT_MODE", "validate")
+	defer os.Unsetenv("INPUT_MODE")
+
+	_, err := generate.LoadParams()
+	assert.EqualError(t, err, "validate mode needs a pull_request event, GITHUB_HEAD_REF or GITHUB_BASE_REF is empty")
+
+	os.Setenv("INPUT_MODE", "lint-commits")
+
+	_, err = generate.LoadParams()
+	assert.EqualError(t, err, "lint-commits mode needs a pull_request event, GITHUB_HEAD_REF or GITHUB_BASE_REF is empty")
+}
+
+func TestLoadParams_Convention(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, conventional.Default(), params.Convention)
+
+	os.Setenv("INPUT_COMMIT_TYPES", "feat, fix,chore")
+	defer os.Unsetenv("INPUT_COMMIT_TYPES")
+
+	os.Setenv("INPUT_COMMIT_SCOPE_REQUIRED", "true")
+	defer os.Unsetenv("INPUT_COMMIT_SCOPE_REQUIRED")
+
+	os.Setenv("INPUT_COMMIT_HEADER_MAX_LENGTH", "72")
+	defer os.Unsetenv("INPUT_COMMIT_HEADER_MAX_LENGTH")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, conventional.Convention{
+		Types:           []string{"feat", "fix", "chore"},
+		RequireScope:    true,
+		MaxHeaderLength: 72,
+	}, params.Convention)
+}
+
+func TestLoadParams_InvalidConvention(t *testing.T) {
+	tests := map[string]struct {
+		Name  string
+		Value string
+		Err   string
+	}{
+		"scope required": {
+			Name:  "INPUT_COMMIT_SCOPE_REQUIRED",
+			Value: "sometimes",
+			Err:   "invalid commit_scope_required argument: sometimes",
+		},
+		"header max length": {
+			Name:  "INPUT_COMMIT_HEADER_MAX_LENGTH",
+			Value: "-1",
+			Err:   "invalid commit_header_max_length argument: -1",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(tc.Name, tc.Value)
+			defer os.Unsetenv(tc.Name)
+
+			_, err := generate.LoadParams()
+			assert.EqualError(t, err, tc.Err)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/snapfi/semver-action/internal/versioning"
+	"github.com/snapfi/semver-action/pkg/git"
+
+	"github.com/apex/log"
+)
+
+// history prints the version tags of a branch and returns the exit code.
+func history(args []string) int {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	var (
+		branch = flags.String("branch", "HEAD", "branch or revision to list the version tags of")
+		format = flags.String("format", "table", "output format: table, json or csv")
+		repo   = flags.String("repo", ".", "repository path")
+		params versioning.Params
+	)
+
+	flags.StringVar(&params.Prefix, "prefix", "v", "prefix of the version tags")
+	flags.StringVar(&params.Scheme, "scheme", "semver", "versioning scheme: semver or calver")
+	flags.StringVar(&params.CalVerFormat, "calver-format", "YYYY.MM.MICRO", "calendar versioning format")
+
+	if err := flags.Parse(args); err != nil {
+		log.Errorf("%s", err)
+		log.Error("usage: semver history [-branch main] [-format table|json|csv] [-prefix v] [-repo .]")
+
+		return exitInvalidParams
+	}
+
+	releases, err := versioning.History(context.Background(), params, git.NewGit(*repo), *branch)
+	if err != nil {
+		log.Errorf("failed to list version history: %s", err)
+
+		return exitCode(err)
+	}
+
+	if err := versioning.WriteHistory(os.Stdout, *format, releases); err != nil {
+		log.Errorf("%s", err)
+
+		return exitInvalidParams
+	}
+
+	return 0
+}
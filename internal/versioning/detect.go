@@ -0,0 +1,120 @@
+package versioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/snapfi/semver-action/pkg/detect"
+
+	"github.com/apex/log"
+)
+
+// gitSource serves the files and commit messages of detectors from git.
+type gitSource struct {
+	ctx context.Context
+	gc  Repository
+}
+
+// Files returns the files matching match at rev.
+func (s gitSource) Files(rev string, match func(path string) bool) (map[string][]byte, error) {
+	return s.gc.Files(s.ctx, rev, match)
+}
+
+// Messages returns the messages of the commits between base and head.
+func (s gitSource) Messages(base, head string) ([]string, error) {
+	messages, err := s.gc.Messages(s.ctx, base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, 0, len(messages))
+	for _, m := range messages {
+		texts = append(texts, m.Text)
+	}
+
+	return texts, nil
+}
+
+// detectChanges runs the configured change detectors between the ancestor tag
+// and the commit and returns the highest detected bump with its explanation.
+func detectChanges(ctx context.Context, params Params, gc Repository, dest string) (string, []string, error) {
+	base, err := gc.AncestorTag(ctx, params.Prefix+"[0-9]*", "", dest)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get ancestor tag: %w", err)
+	}
+
+	head := params.CommitSha
+	if head == "" {
+		head = "HEAD"
+	}
+
+	var (
+		bumps   []string
+		explain []string
+	)
+
+	for _, name := range params.ChangeDetectors {
+		detector, err := detect.New(name)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if c, ok := detector.(detect.Conventional); ok {
+			c.Convention = params.Convention
+			detector = c
+		}
+
+		change, err := detector.Detect(gitSource{ctx: ctx, gc: gc}, base, head)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", detector.Name(), err)
+		}
+
+		log.Debugf("%s change: %q, details: %q", detector.Name(), change.Bump, change.Details)
+
+		bumps = append(bumps, change.Bump)
+
+		bump := change.Bump
+		if bump == "" {
+			bump = "none"
+		}
+
+		explain = append(explain, fmt.Sprintf("%s change since %s: %s", detector.Name(), base, bump))
+		for _, detail := range change.Details {
+			explain = append(explain, "  "+detail)
+		}
+	}
+
+	return detect.Max(bumps...), explain, nil
+}
+
+// combineBump applies a detected bump to the bump strategy, either as a floor
+// raising it or as an override replacing it. A floor doesn't release a merge
+// the strategy doesn't release for a patch, which detectors report when the
+// API didn't change.
+func combineBump(method, version, detected, mode string) (string, string) {
+	current := method
+	if method == "build" || method == "" {
+		current = version
+	}
+
+	next := current
+
+	switch mode {
+	case "floor":
+		if current != "" || detected != "patch" {
+			next = detect.Max(current, detected)
+		}
+	case "override":
+		next = detected
+	}
+
+	if method == "build" || method == "" {
+		if next == "" {
+			return "", ""
+		}
+
+		return "build", next
+	}
+
+	return next, ""
+}
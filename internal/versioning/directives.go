@@ -0,0 +1,113 @@
+package versioning
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/snapfi/semver-action/pkg/conventional"
+
+	"github.com/blang/semver/v4"
+)
+
+// nolint: gochecknoglobals
+var skipMarkerRegex = regexp.MustCompile(`(?i)\[(skip release|no bump)\]`)
+
+// directives are release instructions read from the merge commit message.
+type directives struct {
+	// Skip is the marker skipping the release, if any.
+	Skip string
+	// ReleaseAs is the exact version set by a Release-As trailer, if any.
+	ReleaseAs string
+}
+
+// parseDirectives reads the [skip release] and [no bump] markers anywhere in
+// message and the Release-As trailer from its last paragraph.
+func parseDirectives(message string) directives {
+	d := directives{Skip: skipMarkerRegex.FindString(message)}
+
+	for _, trailer := range conventional.Trailers(message) {
+		if strings.EqualFold(trailer.Token, "Release-As") {
+			d.ReleaseAs = strings.TrimSpace(trailer.Value)
+		}
+	}
+
+	return d
+}
+
+// releaseAs returns the version set by a Release-As trailer, which must be
+// greater than the latest tag. A retracted version moves to the next one.
+func releaseAs(ctx context.Context, params Params, gc Repository, dest, value string) (Result, error) {
+	scheme, err := newScheme(params)
+	if err != nil {
+		return Result{}, err
+	}
+
+	version, err := scheme.Parse(strings.TrimPrefix(value, params.Prefix))
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid Release-As version %q: %w", value, err)
+	}
+
+	latest, _ := semver.New(tagDefault)
+
+	latestTag, err := gc.LatestTag(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get latest tag: %w", err)
+	}
+
+	if latestTag != "" {
+		parsed, err := scheme.Parse(strings.TrimPrefix(latestTag, params.Prefix))
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to parse tag %q or not valid semantic version: %w", latestTag, err)
+		}
+
+		latest = &parsed
+	}
+
+	if !version.GT(*latest) {
+		return Result{}, fmt.Errorf(
+			"version %s of Release-As must be greater than the latest tag %s", value, params.Prefix+scheme.Render(*latest))
+	}
+
+	var previousTag string
+	if latestTag != "" {
+		previousTag = params.Prefix + scheme.Render(*latest)
+	}
+
+	finalTag, skipped, err := skipRetracted(params, scheme, params.Prefix+scheme.Render(version))
+	if err != nil {
+		return Result{}, err
+	}
+
+	exists, err := gc.TagExists(ctx, finalTag)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if exists {
+		return Result{}, fmt.Errorf("%w: %s", ErrVersionExists, finalTag)
+	}
+
+	includePattern := fmt.Sprintf("%s[0-9]*", params.Prefix)
+	excludePattern := fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
+
+	if len(version.Pre) > 0 {
+		includePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, version.Pre[0])
+		excludePattern = ""
+	}
+
+	ancestorTag, err := gc.AncestorTag(ctx, includePattern, excludePattern, dest)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get ancestor tag: %w", err)
+	}
+
+	return Result{
+		PreviousTag:  previousTag,
+		AncestorTag:  ancestorTag,
+		SemverTag:    finalTag,
+		IsPrerelease: len(version.Pre) > 0,
+		Rule:         "Release-As trailer",
+		Explain:      append([]string{fmt.Sprintf("Release-As: %s in the commit message", value)}, skipped...),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package versioning
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/snapfi/semver-action/pkg/git"
+)
+
+// nolint: gochecknoglobals
+var (
+	// ErrNotRepository is returned if the repository directory is not a git repository.
+	ErrNotRepository = git.ErrNotRepository
+	// ErrInvalidBumpStrategy is returned if no branch rule matches the merge.
+	ErrInvalidBumpStrategy = errors.New("invalid bump strategy")
+	// ErrNoPrerelease is returned if there is no prerelease to finalize.
+	ErrNoPrerelease = errors.New("no prerelease found")
+	// ErrVersionExists is returned if the calculated version is already tagged.
+	ErrVersionExists = errors.New("version already exists")
+	// ErrAlreadyGraduated is returned if graduating a version past initial development.
+	ErrAlreadyGraduated = errors.New("has already graduated from initial development")
+)
+
+// BranchError is returned in validate mode if the head branch of the pull
+// request matches no branch rule.
+type BranchError struct {
+	Branch string
+	Base   string
+}
+
+func (e *BranchError) Error() string {
+	return fmt.Sprintf("branch %q matches no branch rule for merges into %s, its name must start with %s",
+		e.Branch, e.Base, strings.Join(branchPrefixes, ", "))
+}
+
+func (e *BranchError) Unwrap() error {
+	return ErrInvalidBumpStrategy
+}
+
+// CommitsError is returned in lint-commits mode if commits of the pull
+// request break the commit convention.
+type CommitsError struct {
+	Commits []CommitProblems
+}
+
+// CommitProblems lists the rules a commit message breaks.
+type CommitProblems struct {
+	Hash     string
+	Header   string
+	Problems []string
+}
+
+func (e *CommitsError) Error() string {
+	return fmt.Sprintf("%d commit messages break the commit convention", len(e.Commits))
+}
@@ -0,0 +1,780 @@
+// Package versioning calculates versions from the tags and history of a git
+// repository. The action and the semver library package are built on it.
+package versioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/snapfi/semver-action/pkg/formatter"
+	"github.com/snapfi/semver-action/pkg/git"
+
+	"github.com/apex/log"
+	"github.com/blang/semver/v4"
+)
+
+// nolint: gochecknoglobals
+var (
+	branchBugfixPrefixRegex  = regexp.MustCompile(`(?i)^(.+:)?(bugfix/.+)`)
+	branchDocPrefixRegex     = regexp.MustCompile(`(?i)^(.+:)?(docs?/.+)`)
+	branchFeaturePrefixRegex = regexp.MustCompile(`(?i)^(.+:)?(feature/.+)`)
+	branchMajorPrefixRegex   = regexp.MustCompile(`(?i)^(.+:)?(major/.+)`)
+	branchMiscPrefixRegex    = regexp.MustCompile(`(?i)^(.+:)?(misc/.+)`)
+	branchPromotePrefixRegex = regexp.MustCompile(`(?i)^(.+:)?promote/(.+)`)
+
+	// branchPrefixes lists the prefixes matched by the branch rules.
+	branchPrefixes = []string{"major/", "feature/", "bugfix/", "promote/", "doc/", "docs/", "misc/"}
+)
+
+const tagDefault = "0.0.0"
+
+type (
+	// Repository is the git repository the version is calculated for.
+	// *git.Client and *git.Snapshot implement it.
+	Repository interface {
+		CurrentBranch(ctx context.Context) (string, error)
+		IsRepo(ctx context.Context) (bool, error)
+		MakeSafe(ctx context.Context) error
+		LatestTag(ctx context.Context) (string, error)
+		AncestorTag(ctx context.Context, include, exclude, branch string) (string, error)
+		Describe(ctx context.Context, include, rev string) (git.Description, error)
+		SourceBranch(ctx context.Context, commitHash string) (string, error)
+		CommitMessage(ctx context.Context, rev string) (string, error)
+		TagExists(ctx context.Context, tag string) (bool, error)
+		IsAncestor(ctx context.Context, ancestor, commit string) (bool, error)
+		Files(ctx context.Context, rev string, match func(path string) bool) (map[string][]byte, error)
+		Commits(ctx context.Context, from, to string) ([]git.Commit, error)
+		Messages(ctx context.Context, from, to string) ([]git.Message, error)
+		ChangedFiles(ctx context.Context, from, to string) ([]string, error)
+	}
+
+	// Result is the calculated version.
+	Result struct {
+		// PreviousTag is the tag the version is calculated from, empty if there is no tag yet.
+		PreviousTag  string
+		AncestorTag  string
+		SemverTag    string
+		IsPrerelease bool
+		// Skipped is true if a marker in the commit message skipped the release.
+		Skipped bool
+		// Snapshot is true if the version is a snapshot of the commit, which
+		// isn't tagged.
+		Snapshot bool
+		// BumpType is the highest part of the version changed from the
+		// previous tag: major, minor, patch, prerelease or finalize. It is
+		// none if nothing is released.
+		BumpType string
+		// Reason tells why nothing is released, empty if a version is.
+		Reason string
+		// Source is the merged branch of a released version, empty if it
+		// wasn't merged from one.
+		Source string
+		// Rule is what picked the bump of a released version, e.g.
+		// feature/ into main or bump input major.
+		Rule string
+		// Explain contains details on how the version was calculated.
+		Explain []string
+		// Formats contains the version rendered for each ecosystem, keyed by output name.
+		Formats map[string]string
+	}
+)
+
+// Calculate returns the calculated version with its outputs for every ecosystem.
+func Calculate(ctx context.Context, params Params, gc Repository) (Result, error) {
+	result, err := Tag(ctx, params, gc)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Lookups such as the latest tag don't report failures, so a result
+	// calculated after the deadline may be based on missing tags.
+	if err := ctx.Err(); err != nil {
+		return Result{}, fmt.Errorf("failed to calculate version in time: %w", err)
+	}
+
+	retracted, err := retractionExplain(ctx, params, gc)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result.Explain = append(retracted, result.Explain...)
+
+	if !result.ShouldRelease() {
+		result.BumpType = "none"
+
+		return result, nil
+	}
+
+	scheme, err := newScheme(params)
+	if err != nil {
+		return Result{}, err
+	}
+
+	version, err := scheme.Parse(strings.TrimPrefix(result.SemverTag, params.Prefix))
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse generated tag %q: %w", result.SemverTag, err)
+	}
+
+	// The first version is bumped from 0.0.0.
+	var previous semver.Version
+
+	if result.PreviousTag != "" {
+		previous, err = scheme.Parse(strings.TrimPrefix(result.PreviousTag, params.Prefix))
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to parse previous tag %q: %w", result.PreviousTag, err)
+		}
+	}
+
+	result.BumpType = bumpType(previous, version)
+
+	if params.GoModule {
+		explain, err := checkGoModule(params, version)
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid go module: %w", err)
+		}
+
+		result.Explain = append(result.Explain, explain...)
+	}
+
+	result.Formats = formatter.All(version)
+
+	return result, nil
+}
+
+// ShouldRelease returns true if a version is released.
+func (r Result) ShouldRelease() bool {
+	return r.SemverTag != ""
+}
+
+// bumpType returns the highest part of the version changed from previous to
+// next.
+func bumpType(previous, next semver.Version) string {
+	switch {
+	case next.Major != previous.Major:
+		return "major"
+	case next.Minor != previous.Minor:
+		return "minor"
+	case next.Patch != previous.Patch:
+		return "patch"
+	case len(next.Pre) == 0 && len(previous.Pre) > 0:
+		return "finalize"
+	default:
+		return "prerelease"
+	}
+}
+
+// Tag returns the calculated semantic version.
+// nolint:gocyclo
+func Tag(ctx context.Context, params Params, gc Repository) (Result, error) {
+	err := gc.MakeSafe(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to make safe: %w", err)
+	}
+
+	isRepo, err := gc.IsRepo(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if !isRepo {
+		return Result{}, fmt.Errorf("current folder is %w", ErrNotRepository)
+	}
+
+	// Explicit bumps aren't merges, so the commit message doesn't apply.
+	var directive directives
+
+	if params.Bump == "auto" {
+		head := params.CommitSha
+		if head == "" {
+			head = "HEAD"
+		}
+
+		message, err := gc.CommitMessage(ctx, head)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to read commit message: %w", err)
+		}
+
+		directive = parseDirectives(message)
+
+		if directive.Skip != "" {
+			reason := fmt.Sprintf("release skipped by %s in the commit message", directive.Skip)
+
+			latestTag, err := gc.LatestTag(ctx)
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to get latest tag: %w", err)
+			}
+
+			return Result{
+				PreviousTag: latestTag,
+				Skipped:     true,
+				Reason:      reason,
+				Explain:     []string{reason},
+			}, nil
+		}
+	}
+
+	dest, err := gc.CurrentBranch(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to extract dest branch from commit: %w", err)
+	}
+
+	log.Debugf("dest branch: %q\n", dest)
+
+	// Exact versions are released from the main branch only.
+	if directive.ReleaseAs != "" && dest == params.BranchName {
+		return releaseAs(ctx, params, gc, dest, directive.ReleaseAs)
+	}
+
+	// Snapshots version any commit, merged from a branch or not.
+	if params.Bump == "snapshot" {
+		return snapshot(ctx, params, gc, dest)
+	}
+
+	// Finalizing and graduating release the versions on the branch as they
+	// are, on any commit, merged from a branch or not.
+	if params.Bump == "finalize" || params.Bump == "graduate" {
+		scheme, err := newScheme(params)
+		if err != nil {
+			return Result{}, err
+		}
+
+		release := finalize
+		if params.Bump == "graduate" {
+			release = graduate
+		}
+
+		result, err := release(ctx, params, gc, dest, scheme)
+		if err != nil {
+			return Result{}, err
+		}
+
+		result.Source, err = mergedBranch(ctx, gc, params.CommitSha)
+		if err != nil {
+			return Result{}, err
+		}
+
+		result.Rule = "bump input " + params.Bump
+
+		return result, nil
+	}
+
+	source, err := gc.SourceBranch(ctx, params.CommitSha)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to extract source branch from commit: %w", err)
+	}
+
+	log.Debugf("source branch: %q\n", source)
+
+	var (
+		method, version string
+		explain         []string
+		delegated       bool
+	)
+
+	if params.Bump == "auto" && params.StrategyPlugin != "" {
+		method, version, explain, delegated, err = pluginBumpStrategy(ctx, params, gc, source, dest)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to run strategy plugin: %w", err)
+		}
+	}
+
+	if !delegated {
+		method, version, err = determineBumpStrategy(params.Bump, source, dest, params.BranchName)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to determine bump strategy: %w", err)
+		}
+	}
+
+	var reason string
+
+	switch {
+	case method != "" || version != "":
+	case delegated:
+		reason = "the strategy plugin doesn't release"
+	default:
+		reason = fmt.Sprintf("merging %s into %s doesn't release", source, dest)
+	}
+
+	// The change detectors refine the bump, but don't overrule a strategy
+	// plugin deciding not to release.
+	pluginSkips := delegated && method == "" && version == ""
+
+	if len(params.ChangeDetectors) > 0 && isVersionBump(method) && !pluginSkips {
+		detected, details, err := detectChanges(ctx, params, gc, dest)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to detect changes: %w", err)
+		}
+
+		method, version = combineBump(method, version, detected, params.ChangeDetectorMode)
+		explain = append(explain, details...)
+	}
+
+	if method == "" && version == "" {
+		if reason == "" {
+			reason = "the change detectors found nothing to release"
+		}
+
+		latestTag, err := gc.LatestTag(ctx)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to get latest tag: %w", err)
+		}
+
+		// The latest tag is still the current version, e.g. to deploy it.
+		return Result{
+			PreviousTag: latestTag,
+			Reason:      reason,
+			Explain:     explain,
+		}, nil
+	}
+
+	log.Debugf("method: %q, version: %q", method, version)
+
+	if method == "promote" && params.PromoteTo == "" {
+		if match := branchPromotePrefixRegex.FindStringSubmatch(source); match != nil &&
+			channelIndex(params.PrereleaseChannels, match[2]) >= 0 {
+			params.PromoteTo = match[2]
+		}
+	}
+
+	scheme, err := newScheme(params)
+	if err != nil {
+		return Result{}, err
+	}
+
+	rule := "bump input " + params.Bump
+
+	switch {
+	case delegated:
+		rule = "strategy plugin"
+	case params.Bump == "auto":
+		rule = fmt.Sprintf("%s into %s", branchRule(source), dest)
+	}
+
+	var tag *semver.Version
+
+	latestTag, err := gc.LatestTag(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get latest tag: %w", err)
+	}
+
+	if latestTag == "" {
+		tag, _ = semver.New(tagDefault)
+	} else {
+		parsed, err := scheme.Parse(strings.TrimPrefix(latestTag, params.Prefix))
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to parse tag %q or not valid semantic version: %w", latestTag, err)
+		}
+		tag = &parsed
+	}
+
+	// Without tags there is no previous tag, as when nothing is released.
+	var previousTag string
+	if latestTag != "" {
+		previousTag = params.Prefix + scheme.Render(*tag)
+	}
+
+	if params.BaseVersion != nil {
+		// Copied, as the tag is incremented in place.
+		base := *params.BaseVersion
+		tag = &base
+	}
+
+	// The channel of the prerelease line the version continues, if any.
+	var channel string
+	if len(tag.Pre) > 1 {
+		channel = tag.Pre[0].VersionStr
+	}
+
+	if params.InitialDevelopment && tag.Major == 0 {
+		method, version = shiftBump(method), shiftBump(version)
+
+		log.Debugf("initial development, shifted to method: %q, version: %q", method, version)
+	}
+
+	bump := method
+	if method == "build" {
+		bump = version
+	}
+
+	if bump == "major" || bump == "minor" || bump == "patch" {
+		log.Debugf("incrementing %s", bump)
+
+		if err := scheme.Increment(tag, bump); err != nil {
+			return Result{}, fmt.Errorf("failed to increment %s version: %w", bump, err)
+		}
+	}
+
+	var (
+		finalTag       string
+		ancestorTag    string
+		includePattern string
+		excludePattern string
+		isPrerelease   bool
+	)
+
+	switch method {
+	case "build":
+		{
+			isPrerelease = true
+			includePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
+
+			buildNumber, _ := semver.NewPRVersion("0")
+
+			// Bumps of a prerelease line stay on its channel or a later one.
+			// Channels are only ordered within a base version, so a new
+			// version line may start on any channel.
+			if channel != "" && version == "" {
+				current := channelIndex(params.PrereleaseChannels, channel)
+				next := channelIndex(params.PrereleaseChannels, params.PrereleaseID)
+
+				if current >= 0 && next >= 0 && next < current {
+					return Result{}, fmt.Errorf(
+						"cannot move prerelease channel backwards from %q to %q", channel, params.PrereleaseID)
+				}
+			}
+
+			// Keep counting only while we stay on the same channel.
+			if len(tag.Pre) > 1 && version == "" && tag.Pre[0].VersionStr == params.PrereleaseID {
+				buildNumber = tag.Pre[1]
+			}
+
+			tag.Pre = nil
+
+			preVersion, err := semver.NewPRVersion(params.PrereleaseID)
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to create new prerelease version: %w", err)
+			}
+
+			tag.Pre = append(tag.Pre, preVersion)
+
+			buildVersion, err := semver.NewPRVersion(strconv.Itoa(int(buildNumber.VersionNum + 1)))
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to create new build version: %w", err)
+			}
+
+			tag.Pre = append(tag.Pre, buildVersion)
+
+			finalTag = params.Prefix + scheme.Render(*tag)
+		}
+	case "major", "minor", "patch":
+		if len(tag.Pre) > 0 {
+			isPrerelease = true
+			includePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
+		} else {
+			includePattern = fmt.Sprintf("%s[0-9]*", params.Prefix)
+			excludePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
+		}
+
+		finalTag = params.Prefix + scheme.Render(*tag)
+	case "promote":
+		channel, err := promoteChannel(tag, params.PrereleaseChannels, params.PromoteTo)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to promote prerelease: %w", err)
+		}
+
+		channelVersion, _ := semver.NewPRVersion(channel)
+		buildVersion, _ := semver.NewPRVersion("1")
+
+		tag.Pre = []semver.PRVersion{channelVersion, buildVersion}
+
+		isPrerelease = true
+		includePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, channel)
+		finalTag = params.Prefix + scheme.Render(*tag)
+	default:
+		return Result{}, fmt.Errorf("%w: %s", ErrInvalidBumpStrategy, method)
+	}
+
+	if !params.ForcePrerelease && method != "promote" {
+		isPrerelease = false
+		includePattern = fmt.Sprintf("%s[0-9]*", params.Prefix)
+		excludePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
+		finalTag = params.Prefix + scheme.Render(finalVersion(*tag))
+	}
+
+	finalTag, skipped, err := skipRetracted(params, scheme, finalTag)
+	if err != nil {
+		return Result{}, err
+	}
+
+	explain = append(explain, skipped...)
+
+	ancestorTag, err = gc.AncestorTag(ctx, includePattern, excludePattern, dest)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get ancestor tag: %w", err)
+	}
+
+	return Result{
+		PreviousTag:  previousTag,
+		AncestorTag:  ancestorTag,
+		SemverTag:    finalTag,
+		IsPrerelease: isPrerelease,
+		Source:       source,
+		Rule:         rule,
+		Explain:      explain,
+	}, nil
+}
+
+// mergedBranch returns the branch commit merged, empty if it isn't a merge.
+func mergedBranch(ctx context.Context, gc Repository, commit string) (string, error) {
+	source, err := gc.SourceBranch(ctx, commit)
+	if errors.Is(err, git.ErrNoSourceBranch) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to extract source branch from commit: %w", err)
+	}
+
+	return source, nil
+}
+
+// branchRule returns the prefix of the branch rule matching source, e.g.
+// feature/, or source itself if none does.
+func branchRule(source string) string {
+	name := source
+
+	// Forks name their branches owner:branch.
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	for _, prefix := range branchPrefixes {
+		if strings.HasPrefix(strings.ToLower(name), prefix) {
+			return prefix
+		}
+	}
+
+	return source
+}
+
+// isVersionBump returns true if method bumps the version from the branch
+// rules or an explicit major, minor or patch.
+func isVersionBump(method string) bool {
+	switch method {
+	case "", "build", "major", "minor", "patch":
+		return true
+	default:
+		return false
+	}
+}
+
+// determineBumpStrategy determines the strategy for semver to bump product version.
+func determineBumpStrategy(bump, sourceBranch, destBranch, branchName string) (string, string, error) {
+	if bump != "auto" {
+		return bump, "", nil
+	}
+
+	// bugfix into main branch
+	if branchBugfixPrefixRegex.MatchString(sourceBranch) && destBranch == branchName {
+		return "build", "patch", nil
+	}
+
+	// feature into main branch
+	if branchFeaturePrefixRegex.MatchString(sourceBranch) && destBranch == branchName {
+		return "build", "minor", nil
+	}
+
+	// major into main branch
+	if branchMajorPrefixRegex.MatchString(sourceBranch) && destBranch == branchName {
+		return "build", "major", nil
+	}
+
+	// promote into main branch
+	if branchPromotePrefixRegex.MatchString(sourceBranch) && destBranch == branchName {
+		return "promote", "", nil
+	}
+
+	// docs or misc into main branch
+	if (branchDocPrefixRegex.MatchString(sourceBranch) || branchMiscPrefixRegex.MatchString(sourceBranch)) &&
+		destBranch == branchName {
+		return "", "", nil
+	}
+
+	return "", "", ErrInvalidBumpStrategy
+}
+
+// finalize releases the latest prerelease on the branch as its final version.
+func finalize(ctx context.Context, params Params, gc Repository, dest string, scheme versionScheme) (Result, error) {
+	prerelease, err := gc.AncestorTag(ctx, fmt.Sprintf("%s[0-9]*-*", params.Prefix), "", dest)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get latest prerelease: %w", err)
+	}
+
+	tag, err := scheme.Parse(strings.TrimPrefix(prerelease, params.Prefix))
+	if err != nil || len(tag.Pre) == 0 {
+		return Result{}, fmt.Errorf("%w on branch %q", ErrNoPrerelease, dest)
+	}
+
+	commit := params.CommitSha
+	if commit == "" {
+		commit = "HEAD"
+	}
+
+	contained, err := gc.IsAncestor(ctx, prerelease, commit)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if !contained {
+		return Result{}, fmt.Errorf("prerelease %q is not contained in %q", prerelease, commit)
+	}
+
+	finalTag := params.Prefix + scheme.Render(finalVersion(tag))
+
+	exists, err := gc.TagExists(ctx, finalTag)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if exists {
+		return Result{}, fmt.Errorf("%w: %s", ErrVersionExists, finalTag)
+	}
+
+	ancestorTag, err := gc.AncestorTag(
+		ctx,
+		fmt.Sprintf("%s[0-9]*", params.Prefix),
+		fmt.Sprintf("%s[0-9]*-*", params.Prefix),
+		dest,
+	)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get ancestor tag: %w", err)
+	}
+
+	return Result{
+		PreviousTag: prerelease,
+		AncestorTag: ancestorTag,
+		SemverTag:   finalTag,
+	}, nil
+}
+
+// graduate releases 1.0.0 after the initial development versions.
+func graduate(ctx context.Context, params Params, gc Repository, dest string, scheme versionScheme) (Result, error) {
+	latestTag, err := gc.LatestTag(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get latest tag: %w", err)
+	}
+
+	tag, _ := semver.New(tagDefault)
+
+	if latestTag != "" {
+		parsed, err := scheme.Parse(strings.TrimPrefix(latestTag, params.Prefix))
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to parse tag %q or not valid semantic version: %w", latestTag, err)
+		}
+
+		tag = &parsed
+	}
+
+	// Without tags there is no previous tag, as when nothing is released.
+	var previousTag string
+	if latestTag != "" {
+		previousTag = params.Prefix + scheme.Render(*tag)
+	}
+
+	if params.BaseVersion != nil {
+		tag = params.BaseVersion
+	}
+
+	if tag.Major > 0 {
+		return Result{}, fmt.Errorf("%s %w", tag, ErrAlreadyGraduated)
+	}
+
+	graduated, _ := semver.New("1.0.0")
+
+	finalTag, skipped, err := skipRetracted(params, scheme, params.Prefix+scheme.Render(*graduated))
+	if err != nil {
+		return Result{}, err
+	}
+
+	ancestorTag, err := gc.AncestorTag(
+		ctx,
+		fmt.Sprintf("%s[0-9]*", params.Prefix),
+		fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID),
+		dest,
+	)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get ancestor tag: %w", err)
+	}
+
+	return Result{
+		PreviousTag: previousTag,
+		AncestorTag: ancestorTag,
+		SemverTag:   finalTag,
+		Explain:     skipped,
+	}, nil
+}
+
+// finalVersion returns v without prerelease and build metadata.
+func finalVersion(v semver.Version) semver.Version {
+	v.Pre, v.Build = nil, nil
+
+	return v
+}
+
+// shiftBump lowers a bump by one level, as breaking changes and features
+// only bump minor and patch respectively during initial development.
+func shiftBump(bump string) string {
+	switch bump {
+	case "major":
+		return "minor"
+	case "minor":
+		return "patch"
+	default:
+		return bump
+	}
+}
+
+// promoteChannel returns the prerelease channel tag should move to. The target
+// is the channel following the current one, unless an explicit one is given.
+func promoteChannel(tag *semver.Version, channels []string, target string) (string, error) {
+	if len(channels) == 0 {
+		return "", errors.New("no prerelease channels configured")
+	}
+
+	if len(tag.Pre) == 0 {
+		return "", fmt.Errorf("%s is not a prerelease", tag)
+	}
+
+	current := channelIndex(channels, tag.Pre[0].VersionStr)
+	if current < 0 {
+		return "", fmt.Errorf("prerelease %q is not one of the configured channels", tag.Pre[0].VersionStr)
+	}
+
+	if target == "" {
+		if current == len(channels)-1 {
+			return "", fmt.Errorf("%q is the last prerelease channel", channels[current])
+		}
+
+		return channels[current+1], nil
+	}
+
+	next := channelIndex(channels, target)
+	if next < 0 {
+		return "", fmt.Errorf("prerelease %q is not one of the configured channels", target)
+	}
+
+	if next == current {
+		return "", fmt.Errorf("%s is already on prerelease channel %q", tag, channels[current])
+	}
+
+	if next < current {
+		return "", fmt.Errorf("cannot move prerelease channel backwards from %q to %q", channels[current], target)
+	}
+
+	return target, nil
+}
+
+// channelIndex returns the position of channel in channels, or -1 if not found.
+func channelIndex(channels []string, channel string) int {
+	for i, c := range channels {
+		if strings.EqualFold(c, channel) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,284 @@
+package versioning
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetermineBumpStrategy(t *testing.T) {
+	tests := map[string]struct {
+		SourceBranch    string
+		DestBranch      string
+		Bump            string
+		ExpectedMethod  string
+		ExpectedVersion string
+	}{
+		"source branch bugfix, dest branch main and auto bump": {
+			SourceBranch:    "bugfix/some",
+			DestBranch:      "main",
+			Bump:            "auto",
+			ExpectedMethod:  "build",
+			ExpectedVersion: "patch",
+		},
+		"source branch doc, dest branch main and auto bump": {
+			SourceBranch:    "doc/some",
+			DestBranch:      "main",
+			Bump:            "auto",
+			ExpectedMethod:  "",
+			ExpectedVersion: "",
+		},
+		"source branch feature, dest branch main and auto bump": {
+			SourceBranch:    "feature/some",
+			DestBranch:      "main",
+			Bump:            "auto",
+			ExpectedMethod:  "build",
+			ExpectedVersion: "minor",
+		},
+		"source branch major, dest branch main and auto bump": {
+			SourceBranch:    "major/some",
+			DestBranch:      "main",
+			Bump:            "auto",
+			ExpectedMethod:  "build",
+			ExpectedVersion: "major",
+		},
+		"source branch misc, dest branch main and auto bump": {
+			SourceBranch:    "misc/some",
+			DestBranch:      "main",
+			Bump:            "auto",
+			ExpectedMethod:  "",
+			ExpectedVersion: "",
+		},
+		"source branch promote, dest branch main and auto bump": {
+			SourceBranch:   "promote/rc",
+			DestBranch:     "main",
+			Bump:           "auto",
+			ExpectedMethod: "promote",
+		},
+		"finalize bump": {
+			Bump:           "finalize",
+			ExpectedMethod: "finalize",
+		},
+		"patch bump": {
+			Bump:           "patch",
+			ExpectedMethod: "patch",
+		},
+		"minor bump": {
+			Bump:           "minor",
+			ExpectedMethod: "minor",
+		},
+		"major bump": {
+			Bump:           "major",
+			ExpectedMethod: "major",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			method, version, err := determineBumpStrategy(test.Bump, test.SourceBranch, test.DestBranch, "main")
+			require.NoError(t, err)
+
+			assert.Equal(t, test.ExpectedMethod, method)
+			assert.Equal(t, test.ExpectedVersion, version)
+		})
+	}
+}
+
+func TestDetermineBumpStrategy_InvalidSource(t *testing.T) {
+	_, _, err := determineBumpStrategy("auto", "some-branch", "main", "main")
+
+	assert.EqualError(t, err, "invalid bump strategy")
+}
+
+func TestCombineBump(t *testing.T) {
+	tests := map[string]struct {
+		Method          string
+		Version         string
+		Detected        string
+		Mode            string
+		ExpectedMethod  string
+		ExpectedVersion string
+	}{
+		"floor raises build version": {
+			Method:          "build",
+			Version:         "minor",
+			Detected:        "major",
+			Mode:            "floor",
+			ExpectedMethod:  "build",
+			ExpectedVersion: "major",
+		},
+		"floor keeps higher build version": {
+			Method:          "build",
+			Version:         "major",
+			Detected:        "patch",
+			Mode:            "floor",
+			ExpectedMethod:  "build",
+			ExpectedVersion: "major",
+		},
+		"floor raises explicit bump": {
+			Method:         "patch",
+			Detected:       "minor",
+			Mode:           "floor",
+			ExpectedMethod: "minor",
+		},
+		"floor releases no bump on api change": {
+			Detected:        "minor",
+			Mode:            "floor",
+			ExpectedMethod:  "build",
+			ExpectedVersion: "minor",
+		},
+		"floor keeps no bump on patch": {
+			Detected: "patch",
+			Mode:     "floor",
+		},
+		"override releases no bump": {
+			Detected:        "patch",
+			Mode:            "override",
+			ExpectedMethod:  "build",
+			ExpectedVersion: "patch",
+		},
+		"override lowers build version": {
+			Method:          "build",
+			Version:         "major",
+			Detected:        "patch",
+			Mode:            "override",
+			ExpectedMethod:  "build",
+			ExpectedVersion: "patch",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			method, version := combineBump(test.Method, test.Version, test.Detected, test.Mode)
+
+			assert.Equal(t, test.ExpectedMethod, method)
+			assert.Equal(t, test.ExpectedVersion, version)
+		})
+	}
+}
+
+func TestCheckGoModule(t *testing.T) {
+	tests := map[string]struct {
+		GoMod    string
+		Version  string
+		Rewrite  bool
+		Expected string
+		Explain  []string
+		Error    string
+	}{
+		"matching path": {
+			GoMod:    "module example.com/x/v2\n",
+			Version:  "2.1.0",
+			Expected: "module example.com/x/v2\n",
+		},
+		"mismatching path": {
+			GoMod:    "module example.com/x\n",
+			Version:  "2.0.0",
+			Expected: "module example.com/x\n",
+			Error: "module path \"example.com/x\" declares major version v1 but version is v2," +
+				" module path must be \"example.com/x/v2\"",
+		},
+		"rewrite pending on major bump": {
+			GoMod:    "module example.com/x\n",
+			Version:  "2.0.0",
+			Rewrite:  true,
+			Expected: "module example.com/x\n",
+			Explain:  []string{"go module path must move from example.com/x to example.com/x/v2"},
+		},
+		"no rewrite on major downgrade": {
+			GoMod:    "module example.com/x/v3\n",
+			Version:  "2.0.0",
+			Rewrite:  true,
+			Expected: "module example.com/x/v3\n",
+			Error: "module path \"example.com/x/v3\" declares major version v3 but version is v2," +
+				" module path must be \"example.com/x/v2\"",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(test.GoMod), 0600)
+			require.NoError(t, err)
+
+			explain, err := checkGoModule(
+				Params{RepoDir: dir, GoModule: true, GoModuleRewrite: test.Rewrite},
+				semver.MustParse(test.Version),
+			)
+
+			if test.Error != "" {
+				assert.EqualError(t, err, test.Error)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.Explain, explain)
+
+			data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, string(data))
+		})
+	}
+}
+
+func TestRewriteGoModule(t *testing.T) {
+	tests := map[string]struct {
+		GoMod    string
+		Tag      string
+		Rewrite  bool
+		Expected string
+		Explain  []string
+	}{
+		"major bump": {
+			GoMod:    "module example.com/x\n",
+			Tag:      "v2.0.0",
+			Rewrite:  true,
+			Expected: "module example.com/x/v2\n",
+			Explain:  []string{"go module path rewritten from example.com/x to example.com/x/v2"},
+		},
+		"rewrite disabled": {
+			GoMod:    "module example.com/x\n",
+			Tag:      "v2.0.0",
+			Expected: "module example.com/x\n",
+		},
+		"matching path": {
+			GoMod:    "module example.com/x/v2\n",
+			Tag:      "v2.1.0",
+			Rewrite:  true,
+			Expected: "module example.com/x/v2\n",
+		},
+		"no release": {
+			GoMod:    "module example.com/x\n",
+			Rewrite:  true,
+			Expected: "module example.com/x\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(test.GoMod), 0600)
+			require.NoError(t, err)
+
+			explain, err := RewriteGoModule(
+				Params{RepoDir: dir, Prefix: "v", GoModule: true, GoModuleRewrite: test.Rewrite},
+				Result{SemverTag: test.Tag},
+			)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Explain, explain)
+
+			data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, string(data))
+		})
+	}
+}
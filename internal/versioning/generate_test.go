@@ -0,0 +1,2095 @@
+package versioning_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/snapfi/semver-action/internal/versioning"
+	"github.com/snapfi/semver-action/pkg/conventional"
+	"github.com/snapfi/semver-action/pkg/formatter"
+	"github.com/snapfi/semver-action/pkg/git"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag(t *testing.T) {
+	tests := map[string]struct {
+		CurrentBranch string
+		LatestTag     string
+		AncestorTag   string
+		SourceBranch  string
+		Params        versioning.Params
+		Result        versioning.Result
+	}{
+		"no previous tag": {
+			CurrentBranch: "main",
+			SourceBranch:  "major/some",
+			Params: versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "auto",
+				Prefix:          "v",
+				PrereleaseID:    "alpha",
+				ForcePrerelease: true,
+				BranchName:      "main",
+			},
+			Result: versioning.Result{
+				AncestorTag:  "",
+				SemverTag:    "v1.0.0-alpha.1",
+				IsPrerelease: true,
+				Source:       "major/some",
+				Rule:         "major/ into main",
+			},
+		},
+		"doc branch into main": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.2.1-alpha.1",
+			AncestorTag:   "v0.2.0-alpha.1",
+			SourceBranch:  "doc/some",
+			Params: versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "alpha",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v0.2.1-alpha.1",
+				Reason:      "merging doc/some into main doesn't release",
+			},
+		},
+		"feature branch into main": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.2.1",
+			SourceBranch:  "feature/some",
+			Params: versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "auto",
+				Prefix:          "v",
+				PrereleaseID:    "alpha",
+				ForcePrerelease: true,
+				BranchName:      "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v0.2.1",
+				SemverTag:    "v0.3.0-alpha.1",
+				IsPrerelease: true,
+				Source:       "feature/some",
+				Rule:         "feature/ into main",
+			},
+		},
+		"bugfix branch into main": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.2.1",
+			AncestorTag:   "v0.2.1-alpha.2",
+			SourceBranch:  "bugfix/some",
+			Params: versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "auto",
+				Prefix:          "v",
+				PrereleaseID:    "alpha",
+				ForcePrerelease: true,
+				BranchName:      "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v0.2.1",
+				AncestorTag:  "v0.2.1-alpha.2",
+				SemverTag:    "v0.2.2-alpha.1",
+				IsPrerelease: true,
+				Source:       "bugfix/some",
+				Rule:         "bugfix/ into main",
+			},
+		},
+		"misc branch into main": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.2.1-alpha.1",
+			AncestorTag:   "v0.2.0-alpha.1",
+			SourceBranch:  "misc/some",
+			Params: versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "alpha",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v0.2.1-alpha.1",
+				Reason:      "merging misc/some into main doesn't release",
+			},
+		},
+		"valid branch into main with with force_prerelease false and with pre-release latest tag": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.2.1-alpha.1",
+			AncestorTag:   "v0.2.0-alpha.1",
+			SourceBranch:  "feature/some",
+			Params: versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "alpha",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v0.2.1-alpha.1",
+				AncestorTag:  "v0.2.0-alpha.1",
+				SemverTag:    "v0.3.0",
+				IsPrerelease: false,
+				Source:       "feature/some",
+				Rule:         "feature/ into main",
+			},
+		},
+		"valid branch into main with with force_prerelease false": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.2.1",
+			AncestorTag:   "v0.2.0",
+			SourceBranch:  "feature/some",
+			Params: versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "alpha",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v0.2.1",
+				AncestorTag:  "v0.2.0",
+				SemverTag:    "v0.3.0",
+				IsPrerelease: false,
+				Source:       "feature/some",
+				Rule:         "feature/ into main",
+			},
+		},
+		"base version set": {
+			CurrentBranch: "main",
+			LatestTag:     "v2.6.19",
+			SourceBranch:  "feature/semver-initial",
+			Params: versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "auto",
+				BaseVersion:     newSemVerPtr(t, "4.2.0"),
+				Prefix:          "v",
+				PrereleaseID:    "alpha",
+				ForcePrerelease: true,
+				BranchName:      "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v2.6.19",
+				SemverTag:    "v4.3.0-alpha.1",
+				IsPrerelease: true,
+				Source:       "feature/semver-initial",
+				Rule:         "feature/ into main",
+			},
+		},
+		"force bump major": {
+			CurrentBranch: "main",
+			LatestTag:     "v2.6.19-alpha.1",
+			SourceBranch:  "semver-initial",
+			Params: versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "major",
+				Prefix:          "v",
+				PrereleaseID:    "alpha",
+				ForcePrerelease: true,
+				BranchName:      "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v2.6.19-alpha.1",
+				SemverTag:    "v3.0.0-alpha.1",
+				IsPrerelease: true,
+				Source:       "semver-initial",
+				Rule:         "bump input major",
+			},
+		},
+		"force bump minor": {
+			CurrentBranch: "main",
+			LatestTag:     "v2.6.19-alpha.1",
+			SourceBranch:  "semver-initial",
+			Params: versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "minor",
+				Prefix:          "v",
+				PrereleaseID:    "alpha",
+				ForcePrerelease: true,
+				BranchName:      "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v2.6.19-alpha.1",
+				SemverTag:    "v2.7.0-alpha.1",
+				IsPrerelease: true,
+				Source:       "semver-initial",
+				Rule:         "bump input minor",
+			},
+		},
+		"force bump patch": {
+			CurrentBranch: "main",
+			LatestTag:     "v2.6.19-alpha.1",
+			SourceBranch:  "semver-initial",
+			Params: versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "patch",
+				Prefix:          "v",
+				PrereleaseID:    "alpha",
+				ForcePrerelease: true,
+				BranchName:      "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v2.6.19-alpha.1",
+				SemverTag:    "v2.6.20-alpha.1",
+				IsPrerelease: true,
+				Source:       "semver-initial",
+				Rule:         "bump input patch",
+			},
+		},
+		"promote to next channel": {
+			CurrentBranch: "main",
+			LatestTag:     "v2.0.0-beta.4",
+			SourceBranch:  "semver-initial",
+			Params: versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               "promote",
+				Prefix:             "v",
+				PrereleaseID:       "alpha",
+				PrereleaseChannels: []string{"alpha", "beta", "rc"},
+				BranchName:         "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v2.0.0-beta.4",
+				SemverTag:    "v2.0.0-rc.1",
+				IsPrerelease: true,
+				Source:       "semver-initial",
+				Rule:         "bump input promote",
+			},
+		},
+		"promote branch into main": {
+			CurrentBranch: "main",
+			LatestTag:     "v2.0.0-alpha.2",
+			SourceBranch:  "promote/rc",
+			Params: versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               "auto",
+				Prefix:             "v",
+				PrereleaseID:       "alpha",
+				PrereleaseChannels: []string{"alpha", "beta", "rc"},
+				ForcePrerelease:    true,
+				BranchName:         "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v2.0.0-alpha.2",
+				SemverTag:    "v2.0.0-rc.1",
+				IsPrerelease: true,
+				Source:       "promote/rc",
+				Rule:         "promote/ into main",
+			},
+		},
+		"major branch into main during initial development": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.4.2",
+			SourceBranch:  "major/some",
+			Params: versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               "auto",
+				Prefix:             "v",
+				PrereleaseID:       "pre",
+				InitialDevelopment: true,
+				BranchName:         "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v0.4.2",
+				SemverTag:   "v0.5.0",
+				Source:      "major/some",
+				Rule:        "major/ into main",
+			},
+		},
+		"feature branch into main during initial development": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.4.2",
+			SourceBranch:  "feature/some",
+			Params: versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               "auto",
+				Prefix:             "v",
+				PrereleaseID:       "pre",
+				ForcePrerelease:    true,
+				InitialDevelopment: true,
+				BranchName:         "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v0.4.2",
+				SemverTag:    "v0.4.3-pre.1",
+				IsPrerelease: true,
+				Source:       "feature/some",
+				Rule:         "feature/ into main",
+			},
+		},
+		"major branch into main after initial development": {
+			CurrentBranch: "main",
+			LatestTag:     "v1.4.2",
+			SourceBranch:  "major/some",
+			Params: versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               "auto",
+				Prefix:             "v",
+				PrereleaseID:       "pre",
+				InitialDevelopment: true,
+				BranchName:         "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v1.4.2",
+				SemverTag:   "v2.0.0",
+				Source:      "major/some",
+				Rule:        "major/ into main",
+			},
+		},
+		"graduate": {
+			CurrentBranch: "main",
+			LatestTag:     "v0.4.2",
+			SourceBranch:  "semver-initial",
+			Params: versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               "graduate",
+				Prefix:             "v",
+				PrereleaseID:       "pre",
+				InitialDevelopment: true,
+				BranchName:         "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v0.4.2",
+				SemverTag:   "v1.0.0",
+				Source:      "semver-initial",
+				Rule:        "bump input graduate",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gc := initGitClientMock(
+				t,
+				test.LatestTag,
+				test.AncestorTag,
+				test.CurrentBranch,
+				test.SourceBranch,
+				test.Params.CommitSha,
+			)
+
+			result, err := versioning.Tag(context.Background(), test.Params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Result, result)
+		})
+	}
+}
+
+func TestTag_InvalidBranchName(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:    "81918ffc",
+		Bump:         "auto",
+		Prefix:       "v",
+		PrereleaseID: "alpha",
+		BranchName:   "main",
+	}
+
+	gc := initGitClientMock(
+		t,
+		"v2.6.19-alpha.1",
+		"",
+		"main",
+		"semver-initial",
+		"81918ffc",
+	)
+
+	result, err := versioning.Tag(context.Background(), params, gc)
+
+	assert.EqualError(t, err, "failed to determine bump strategy: invalid bump strategy")
+	assert.ErrorIs(t, err, versioning.ErrInvalidBumpStrategy)
+
+	assert.Empty(t, result)
+}
+
+func TestTag_PrereleaseChannel(t *testing.T) {
+	// The build bump continues the prerelease line of the latest tag.
+	tests := map[string]struct {
+		Bump            string
+		PrereleaseID    string
+		ForcePrerelease bool
+		Expected        string
+		Err             string
+	}{
+		"backwards from rc to alpha": {
+			Bump:            "build",
+			PrereleaseID:    "alpha",
+			ForcePrerelease: true,
+			Err:             "cannot move prerelease channel backwards from \"rc\" to \"alpha\"",
+		},
+		"same channel": {
+			Bump:            "build",
+			PrereleaseID:    "rc",
+			ForcePrerelease: true,
+			Expected:        "v2.0.0-rc.4",
+		},
+		"new version line on an earlier channel": {
+			Bump:            "auto",
+			PrereleaseID:    "alpha",
+			ForcePrerelease: true,
+			Expected:        "v2.1.0-alpha.1",
+		},
+		"new version line on the same channel": {
+			Bump:            "auto",
+			PrereleaseID:    "rc",
+			ForcePrerelease: true,
+			Expected:        "v2.1.0-rc.1",
+		},
+		"final release": {
+			Bump:         "auto",
+			PrereleaseID: "alpha",
+			Expected:     "v2.1.0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               test.Bump,
+				Prefix:             "v",
+				PrereleaseID:       test.PrereleaseID,
+				PrereleaseChannels: []string{"alpha", "beta", "rc"},
+				ForcePrerelease:    test.ForcePrerelease,
+				BranchName:         "main",
+			}
+
+			gc := initGitClientMock(t, "v2.0.0-rc.3", "v2.0.0-rc.3", "main", "feature/some", "81918ffc")
+
+			result, err := versioning.Tag(context.Background(), params, gc)
+			if test.Err != "" {
+				assert.EqualError(t, err, test.Err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, result.SemverTag)
+		})
+	}
+}
+
+func TestTag_PromoteErr(t *testing.T) {
+	tests := map[string]struct {
+		LatestTag string
+		PromoteTo string
+		Expected  string
+	}{
+		"backwards": {
+			LatestTag: "v2.0.0-rc.1",
+			PromoteTo: "alpha",
+			Expected: "failed to promote prerelease: " +
+				"cannot move prerelease channel backwards from \"rc\" to \"alpha\"",
+		},
+		"last channel": {
+			LatestTag: "v2.0.0-rc.1",
+			Expected:  "failed to promote prerelease: \"rc\" is the last prerelease channel",
+		},
+		"not a prerelease": {
+			LatestTag: "v2.0.0",
+			Expected:  "failed to promote prerelease: 2.0.0 is not a prerelease",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               "promote",
+				Prefix:             "v",
+				PrereleaseID:       "alpha",
+				PrereleaseChannels: []string{"alpha", "beta", "rc"},
+				PromoteTo:          test.PromoteTo,
+				BranchName:         "main",
+			}
+
+			gc := initGitClientMock(t, test.LatestTag, "", "main", "semver-initial", "81918ffc")
+
+			_, err := versioning.Tag(context.Background(), params, gc)
+
+			assert.EqualError(t, err, test.Expected)
+		})
+	}
+}
+
+func TestTag_Finalize(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:       "81918ffc",
+		Bump:            "finalize",
+		Prefix:          "v",
+		PrereleaseID:    "pre",
+		ForcePrerelease: true,
+		BranchName:      "main",
+	}
+
+	gc := initGitClientMock(t, "v1.6.0-pre.7", "", "main", "semver-initial", "81918ffc")
+	gc.AncestorTagFn = func(include, exclude, branch string) (string, error) {
+		assert.Equal(t, "main", branch)
+
+		if include == "v[0-9]*-*" {
+			return "v1.6.0-pre.7", nil
+		}
+
+		return "v1.5.0", nil
+	}
+	gc.IsAncestorFn = func(ancestor, commit string) (bool, error) {
+		assert.Equal(t, "v1.6.0-pre.7", ancestor)
+		assert.Equal(t, "81918ffc", commit)
+
+		return true, nil
+	}
+	gc.TagExistsFn = func(tag string) (bool, error) {
+		assert.Equal(t, "v1.6.0", tag)
+
+		return false, nil
+	}
+
+	result, err := versioning.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, versioning.Result{
+		PreviousTag: "v1.6.0-pre.7",
+		AncestorTag: "v1.5.0",
+		SemverTag:   "v1.6.0",
+		Source:      "semver-initial",
+		Rule:        "bump input finalize",
+	}, result)
+}
+
+func TestTag_FinalizeWithoutMerge(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:    "81918ffc",
+		Bump:         "finalize",
+		Prefix:       "v",
+		PrereleaseID: "pre",
+		BranchName:   "main",
+	}
+
+	gc := initGitClientMock(t, "v1.6.0-pre.7", "v1.6.0-pre.7", "main", "", "81918ffc")
+	gc.SourceBranchFn = func(commitHash string) (string, error) {
+		return "", fmt.Errorf("%w: commit message does not contain expected format: fix typo", git.ErrNoSourceBranch)
+	}
+
+	result, err := versioning.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, versioning.Result{
+		PreviousTag: "v1.6.0-pre.7",
+		AncestorTag: "v1.6.0-pre.7",
+		SemverTag:   "v1.6.0",
+		Rule:        "bump input finalize",
+	}, result)
+}
+
+func TestTag_FinalizeErr(t *testing.T) {
+	tests := map[string]struct {
+		Prerelease string
+		IsAncestor bool
+		TagExists  bool
+		Expected   string
+		Sentinel   error
+	}{
+		"no prerelease": {
+			Prerelease: "da81ce0ec20cab645ffe03e760dad1cdfccf7c94",
+			Expected:   "no prerelease found on branch \"main\"",
+			Sentinel:   versioning.ErrNoPrerelease,
+		},
+		"not contained in head": {
+			Prerelease: "v1.6.0-pre.7",
+			Expected:   "prerelease \"v1.6.0-pre.7\" is not contained in \"81918ffc\"",
+		},
+		"already released": {
+			Prerelease: "v1.6.0-pre.7",
+			IsAncestor: true,
+			TagExists:  true,
+			Expected:   "version already exists: v1.6.0",
+			Sentinel:   versioning.ErrVersionExists,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:  "81918ffc",
+				Bump:       "finalize",
+				Prefix:     "v",
+				BranchName: "main",
+			}
+
+			gc := initGitClientMock(t, "", test.Prerelease, "main", "semver-initial", "81918ffc")
+			gc.IsAncestorFn = func(ancestor, commit string) (bool, error) {
+				return test.IsAncestor, nil
+			}
+			gc.TagExistsFn = func(tag string) (bool, error) {
+				return test.TagExists, nil
+			}
+
+			_, err := versioning.Tag(context.Background(), params, gc)
+
+			assert.EqualError(t, err, test.Expected)
+
+			if test.Sentinel != nil {
+				assert.ErrorIs(t, err, test.Sentinel)
+			}
+		})
+	}
+}
+
+func TestCalculate_NoTags(t *testing.T) {
+	tests := map[string]struct {
+		SourceBranch string
+		SemverTag    string
+		BumpType     string
+	}{
+		"release": {
+			SourceBranch: "feature/some",
+			SemverTag:    "v0.1.0",
+			BumpType:     "minor",
+		},
+		"no release": {
+			SourceBranch: "docs/some",
+			BumpType:     "none",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+			}
+
+			gc := initGitClientMock(t, "", "", "main", test.SourceBranch, "81918ffc")
+
+			result, err := versioning.Calculate(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			// Both paths report the missing tag the same way.
+			assert.Empty(t, result.PreviousTag)
+			assert.Equal(t, test.SemverTag, result.SemverTag)
+			assert.Equal(t, test.BumpType, result.BumpType)
+		})
+	}
+}
+
+func TestTag_GraduateErr(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:  "81918ffc",
+		Bump:       "graduate",
+		Prefix:     "v",
+		BranchName: "main",
+	}
+
+	gc := initGitClientMock(t, "v1.4.2", "", "main", "semver-initial", "81918ffc")
+
+	_, err := versioning.Tag(context.Background(), params, gc)
+
+	assert.EqualError(t, err, "1.4.2 has already graduated from initial development")
+	assert.ErrorIs(t, err, versioning.ErrAlreadyGraduated)
+}
+
+func TestTag_GraduateWithoutMerge(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:    "81918ffc",
+		Bump:         "graduate",
+		Prefix:       "v",
+		PrereleaseID: "pre",
+		BranchName:   "main",
+	}
+
+	gc := initGitClientMock(t, "v0.9.4", "v0.9.4", "main", "", "81918ffc")
+	gc.SourceBranchFn = func(commitHash string) (string, error) {
+		return "", fmt.Errorf("%w: commit message does not contain expected format: fix typo", git.ErrNoSourceBranch)
+	}
+
+	result, err := versioning.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, versioning.Result{
+		PreviousTag: "v0.9.4",
+		AncestorTag: "v0.9.4",
+		SemverTag:   "v1.0.0",
+		Rule:        "bump input graduate",
+	}, result)
+}
+
+func TestTag_InvalidBump(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:  "81918ffc",
+		Bump:       "huge",
+		Prefix:     "v",
+		BranchName: "main",
+	}
+
+	gc := initGitClientMock(t, "v1.4.2", "", "main", "feature/some", "81918ffc")
+
+	_, err := versioning.Tag(context.Background(), params, gc)
+
+	assert.EqualError(t, err, "invalid bump strategy: huge")
+	assert.ErrorIs(t, err, versioning.ErrInvalidBumpStrategy)
+}
+
+func TestTag_BaseVersionUnchanged(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:    "81918ffc",
+		Bump:         "auto",
+		BaseVersion:  newSemVerPtr(t, "4.2.0"),
+		Prefix:       "v",
+		PrereleaseID: "alpha",
+		BranchName:   "main",
+	}
+
+	gc := initGitClientMock(t, "v2.6.19", "", "main", "feature/some", "81918ffc")
+
+	first, err := versioning.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	second, err := versioning.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "4.2.0", params.BaseVersion.String())
+	assert.Equal(t, first.SemverTag, second.SemverTag)
+}
+
+func TestTag_CalVer(t *testing.T) {
+	tests := map[string]struct {
+		Format          string
+		Now             time.Time
+		LatestTag       string
+		ForcePrerelease bool
+		Expected        string
+	}{
+		"same period": {
+			Format:    "YY.0M.MICRO",
+			Now:       time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			LatestTag: "v26.10.4",
+			Expected:  "v26.10.5",
+		},
+		"new period": {
+			Format:    "YY.0M.MICRO",
+			Now:       time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			LatestTag: "v20.01.4",
+			Expected:  "v26.10.0",
+		},
+		"prerelease": {
+			Format:          "YY.0M.MICRO",
+			Now:             time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			LatestTag:       "v20.01.4",
+			ForcePrerelease: true,
+			Expected:        "v26.10.0-pre.1",
+		},
+		"week across the year boundary": {
+			Format:    "YYYY.0W.MICRO",
+			Now:       time.Date(2025, time.December, 29, 12, 0, 0, 0, time.UTC),
+			LatestTag: "v2025.52.2",
+			Expected:  "v2026.01.0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "auto",
+				Scheme:          "calver",
+				CalVerFormat:    test.Format,
+				Prefix:          "v",
+				PrereleaseID:    "pre",
+				ForcePrerelease: test.ForcePrerelease,
+				BranchName:      "main",
+				Clock:           func() time.Time { return test.Now },
+			}
+
+			gc := initGitClientMock(t, test.LatestTag, "", "main", "feature/some", "81918ffc")
+
+			result, err := versioning.Tag(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.LatestTag, result.PreviousTag)
+			assert.Equal(t, test.Expected, result.SemverTag)
+		})
+	}
+}
+
+func TestTag_ChangeDetectors(t *testing.T) {
+	tests := map[string]struct {
+		SourceBranch string
+		Detectors    []string
+		Mode         string
+		HeadSrc      string
+		HeadSpec     string
+		Result       versioning.Result
+	}{
+		"floor raises feature to major": {
+			SourceBranch: "feature/some",
+			Detectors:    []string{"go"},
+			Mode:         "floor",
+			HeadSrc:      "package lib\n\nfunc New(addr string, port int) {}\n",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v2.0.0",
+				Source:      "feature/some",
+				Rule:        "feature/ into main",
+				Explain: []string{
+					"go api change since v1.2.0: major",
+					"  changed lib.New",
+				},
+			},
+		},
+		"floor keeps feature without api change": {
+			SourceBranch: "feature/some",
+			Detectors:    []string{"go"},
+			Mode:         "floor",
+			HeadSrc:      "package lib\n\nfunc New(address string) {}\n",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v1.3.0",
+				Source:      "feature/some",
+				Rule:        "feature/ into main",
+				Explain:     []string{"go api change since v1.2.0: patch"},
+			},
+		},
+		"override releases bugfix without api change": {
+			SourceBranch: "bugfix/some",
+			Detectors:    []string{"go"},
+			Mode:         "override",
+			HeadSrc:      "package lib\n\nfunc New(address string) {}\n",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v1.2.1",
+				Source:      "bugfix/some",
+				Rule:        "bugfix/ into main",
+				Explain:     []string{"go api change since v1.2.0: patch"},
+			},
+		},
+		"override lowers major to minor": {
+			SourceBranch: "major/some",
+			Detectors:    []string{"go"},
+			Mode:         "override",
+			HeadSrc:      "package lib\n\nfunc New(addr string) {}\n\nfunc Close() {}\n",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v1.3.0",
+				Source:      "major/some",
+				Rule:        "major/ into main",
+				Explain:     []string{"go api change since v1.2.0: minor"},
+			},
+		},
+		"floor releases docs branch with api change": {
+			SourceBranch: "docs/some",
+			Detectors:    []string{"go"},
+			Mode:         "floor",
+			HeadSrc:      "package lib\n\nfunc New(addr string) {}\n\nfunc Close() {}\n",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v1.3.0",
+				Source:      "docs/some",
+				Rule:        "docs/ into main",
+				Explain:     []string{"go api change since v1.2.0: minor"},
+			},
+		},
+		"floor keeps docs branch without api change": {
+			SourceBranch: "docs/some",
+			Detectors:    []string{"go", "openapi"},
+			Mode:         "floor",
+			HeadSrc:      "package lib\n\n// New connects to addr.\nfunc New(addr string) {}\n",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				Reason:      "merging docs/some into main doesn't release",
+				Explain: []string{
+					"go api change since v1.2.0: patch",
+					"openapi change since v1.2.0: patch",
+				},
+			},
+		},
+		"max of go api and openapi changes": {
+			SourceBranch: "bugfix/some",
+			Detectors:    []string{"go", "openapi"},
+			Mode:         "floor",
+			HeadSrc:      "package lib\n\nfunc New(addr string) {}\n\nfunc Close() {}\n",
+			HeadSpec:     "openapi: 3.0.3\npaths: {}\n",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v2.0.0",
+				Source:      "bugfix/some",
+				Rule:        "bugfix/ into main",
+				Explain: []string{
+					"go api change since v1.2.0: minor",
+					"openapi change since v1.2.0: major",
+					"  removed endpoint GET /pets",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+
+				ChangeDetectors:    test.Detectors,
+				ChangeDetectorMode: test.Mode,
+			}
+
+			gc := initGitClientMock(t, "v1.2.0", "v1.2.0", "main", test.SourceBranch, "81918ffc")
+			gc.FilesFn = func(rev string, match func(path string) bool) (map[string][]byte, error) {
+				files := map[string]string{
+					"lib.go":       "package lib\n\nfunc New(addr string) {}\n",
+					"openapi.yaml": "openapi: 3.0.3\npaths:\n  /pets:\n    get: {}\n",
+				}
+
+				if rev == "81918ffc" {
+					files["lib.go"] = test.HeadSrc
+
+					if test.HeadSpec != "" {
+						files["openapi.yaml"] = test.HeadSpec
+					}
+				} else {
+					assert.Equal(t, "v1.2.0", rev)
+				}
+
+				result := make(map[string][]byte)
+
+				for name, content := range files {
+					if match(name) {
+						result[name] = []byte(content)
+					}
+				}
+
+				return result, nil
+			}
+
+			result, err := versioning.Tag(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Result, result)
+		})
+	}
+}
+
+func TestTag_StrategyPlugin(t *testing.T) {
+	tests := map[string]struct {
+		SourceBranch string
+		LatestTag    string
+		AncestorTag  string
+		Detectors    []string
+		Reply        string
+		PreviousTag  string
+		Result       versioning.Result
+	}{
+		"bump from plugin": {
+			SourceBranch: "feature/some",
+			Reply:        `{"bump": "major", "reason": "api/ changed"}`,
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v2.0.0",
+				Source:      "feature/some",
+				Rule:        "strategy plugin",
+				Explain:     []string{"strategy plugin: major", "  api/ changed"},
+			},
+		},
+		"no release from plugin": {
+			SourceBranch: "feature/some",
+			Reply:        `{"bump": "none"}`,
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				Reason:      "the strategy plugin doesn't release",
+				Explain:     []string{"strategy plugin: none"},
+			},
+		},
+		"plugin defers to branch rules": {
+			SourceBranch: "bugfix/some",
+			Reply:        `{}`,
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v1.2.1",
+				Source:      "bugfix/some",
+				Rule:        "bugfix/ into main",
+			},
+		},
+		"no tags yet": {
+			SourceBranch: "feature/some",
+			LatestTag:    "",
+			AncestorTag:  "0d4e2b17c3a9",
+			Reply:        `{"bump": "none"}`,
+			PreviousTag:  "",
+			Result: versioning.Result{
+				Reason:  "the strategy plugin doesn't release",
+				Explain: []string{"strategy plugin: none"},
+			},
+		},
+		"floor detectors raise the plugin bump": {
+			SourceBranch: "feature/some",
+			Detectors:    []string{"conventional"},
+			Reply:        `{"bump": "minor"}`,
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v2.0.0",
+				Source:      "feature/some",
+				Rule:        "strategy plugin",
+				Explain: []string{
+					"strategy plugin: minor",
+					"conventional commits change since v1.2.0: major",
+					"  breaking change: feat!: drop the v1 api",
+				},
+			},
+		},
+		"floor detectors don't overrule no release from plugin": {
+			SourceBranch: "feature/some",
+			Detectors:    []string{"conventional"},
+			Reply:        `{"bump": "none"}`,
+			Result: versioning.Result{
+				PreviousTag: "v1.2.0",
+				Reason:      "the strategy plugin doesn't release",
+				Explain:     []string{"strategy plugin: none"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			script := "#!/bin/sh\ncat > request.json\necho '" + test.Reply + "'\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.sh"), []byte(script), 0700)) // nolint:gosec
+
+			params := versioning.Params{
+				CommitSha:      "81918ffc",
+				RepoDir:        dir,
+				Bump:           "auto",
+				Prefix:         "v",
+				PrereleaseID:   "pre",
+				StrategyPlugin: "./plugin.sh",
+				BranchName:     "main",
+
+				ChangeDetectors:    test.Detectors,
+				ChangeDetectorMode: "floor",
+			}
+
+			if test.AncestorTag == "" {
+				test.LatestTag, test.AncestorTag, test.PreviousTag = "v1.2.0", "v1.2.0", "v1.2.0"
+			}
+
+			gc := initGitClientMock(t, test.LatestTag, test.AncestorTag, "main", test.SourceBranch, "81918ffc")
+			gc.TagExistsFn = func(tag string) (bool, error) {
+				return tag == "v1.2.0", nil
+			}
+			gc.MessagesFn = func(from, to string) ([]git.Message, error) {
+				return []git.Message{{Hash: "81918ffc", Text: "feat!: drop the v1 api"}}, nil
+			}
+			gc.CommitsFn = func(from, to string) ([]git.Commit, error) {
+				assert.Equal(t, test.AncestorTag, from)
+				assert.Equal(t, "81918ffc", to)
+
+				return []git.Commit{{Hash: "81918ffc", Subject: "Merge pull request #1"}}, nil
+			}
+			gc.ChangedFilesFn = func(from, to string) ([]string, error) {
+				return []string{"api/openapi.yaml"}, nil
+			}
+
+			result, err := versioning.Tag(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Result, result)
+
+			request, err := os.ReadFile(filepath.Join(dir, "request.json"))
+			require.NoError(t, err)
+
+			assert.JSONEq(t, `{
+				"version": 1,
+				"source_branch": "`+test.SourceBranch+`",
+				"dest_branch": "main",
+				"main_branch": "main",
+				"previous_tag": "`+test.PreviousTag+`",
+				"commits": [{"hash": "81918ffc", "subject": "Merge pull request #1"}],
+				"changed_files": ["api/openapi.yaml"]
+			}`, string(request))
+		})
+	}
+}
+
+func TestTag_IsNotRepo(t *testing.T) {
+	gc := &gitClientMock{
+		MakeSafeFn: func() error {
+			return nil
+		},
+		IsRepoFn: func() (bool, error) {
+			return false, nil
+		},
+	}
+
+	_, err := versioning.Tag(context.Background(), versioning.Params{}, gc)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "current folder is not a git repository")
+	assert.ErrorIs(t, err, versioning.ErrNotRepository)
+}
+
+func TestTag_MakeSafeErr(t *testing.T) {
+	gc := &gitClientMock{
+		MakeSafeFn: func() error {
+			return errors.New("error")
+		},
+	}
+
+	_, err := versioning.Tag(context.Background(), versioning.Params{}, gc)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "failed to make safe: error")
+}
+
+func TestCalculate_QueryTimeout(t *testing.T) {
+	timeout := &git.TimeoutError{Args: []string{"describe", "--tags"}}
+
+	tests := map[string]func(gc *gitClientMock){
+		"is repo": func(gc *gitClientMock) {
+			gc.IsRepoFn = func() (bool, error) { return false, timeout }
+		},
+		"latest tag": func(gc *gitClientMock) {
+			gc.LatestTagFn = func() (string, error) { return "", timeout }
+		},
+		"ancestor tag": func(gc *gitClientMock) {
+			gc.AncestorTagFn = func(include, exclude, branch string) (string, error) { return "", timeout }
+		},
+		"tag exists": func(gc *gitClientMock) {
+			gc.TagExistsFn = func(tag string) (bool, error) { return false, timeout }
+		},
+	}
+
+	for name, stall := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "alpha",
+				BranchName:   "main",
+				Retracted:    map[string]string{"v1.2.3": "broke login"},
+			}
+
+			gc := initGitClientMock(t, "v1.2.3", "v1.2.0", "main", "feature/some", "81918ffc")
+			stall(gc)
+
+			_, err := versioning.Calculate(context.Background(), params, gc)
+
+			var timeoutErr *git.TimeoutError
+			assert.True(t, errors.As(err, &timeoutErr), "got %v", err)
+		})
+	}
+}
+
+type gitClientMock struct {
+	CurrentBranchFn        func() (string, error)
+	CurrentBranchFnInvoked int
+	IsRepoFn               func() (bool, error)
+	IsRepoFnInvoked        int
+	MakeSafeFn             func() error
+	MakeSafeFnInvoked      int
+	LatestTagFn            func() (string, error)
+	LatestTagFnInvoked     int
+	AncestorTagFn          func(include, exclude, branch string) (string, error)
+	AncestorTagFnInvoked   int
+	SourceBranchFn         func(commitHash string) (string, error)
+	SourceBranchFnInvoked  int
+	DescribeFn             func(include, rev string) (git.Description, error)
+	DescribeFnInvoked      int
+	CommitMessageFn        func(rev string) (string, error)
+	CommitMessageFnInvoked int
+	TagExistsFn            func(tag string) (bool, error)
+	TagExistsFnInvoked     int
+	IsAncestorFn           func(ancestor, commit string) (bool, error)
+	IsAncestorFnInvoked    int
+	FilesFn                func(rev string, match func(path string) bool) (map[string][]byte, error)
+	FilesFnInvoked         int
+	CommitsFn              func(from, to string) ([]git.Commit, error)
+	CommitsFnInvoked       int
+	MessagesFn             func(from, to string) ([]git.Message, error)
+	MessagesFnInvoked      int
+	ChangedFilesFn         func(from, to string) ([]string, error)
+	ChangedFilesFnInvoked  int
+}
+
+func initGitClientMock(
+	t *testing.T,
+	latestTag,
+	ancestorTag,
+	currentBranch,
+	sourceBranch,
+	expectedCommitHash string) *gitClientMock {
+	return &gitClientMock{
+		CurrentBranchFn: func() (string, error) {
+			return currentBranch, nil
+		},
+		IsRepoFn: func() (bool, error) {
+			return true, nil
+		},
+		MakeSafeFn: func() error {
+			return nil
+		},
+		LatestTagFn: func() (string, error) {
+			return latestTag, nil
+		},
+		AncestorTagFn: func(include, exclude, branch string) (string, error) {
+			return ancestorTag, nil
+		},
+		SourceBranchFn: func(commitHash string) (string, error) {
+			assert.Equal(t, expectedCommitHash, commitHash)
+			return sourceBranch, nil
+		},
+		DescribeFn: func(include, rev string) (git.Description, error) {
+			assert.Equal(t, expectedCommitHash, rev)
+			return git.Description{Tag: ancestorTag, Hash: "81918ff"}, nil
+		},
+		CommitMessageFn: func(rev string) (string, error) {
+			assert.Equal(t, expectedCommitHash, rev)
+			return "Merge pull request #1 from org/" + sourceBranch, nil
+		},
+		TagExistsFn: func(tag string) (bool, error) {
+			return false, nil
+		},
+		IsAncestorFn: func(ancestor, commit string) (bool, error) {
+			return true, nil
+		},
+		CommitsFn: func(from, to string) ([]git.Commit, error) {
+			return nil, nil
+		},
+		MessagesFn: func(from, to string) ([]git.Message, error) {
+			return nil, nil
+		},
+		ChangedFilesFn: func(from, to string) ([]string, error) {
+			return nil, nil
+		},
+	}
+}
+
+func (m *gitClientMock) CurrentBranch(ctx context.Context) (string, error) {
+	m.CurrentBranchFnInvoked++
+	return m.CurrentBranchFn()
+}
+
+func (m *gitClientMock) MakeSafe(ctx context.Context) error {
+	m.MakeSafeFnInvoked++
+	return m.MakeSafeFn()
+}
+
+func (m *gitClientMock) IsRepo(ctx context.Context) (bool, error) {
+	m.IsRepoFnInvoked++
+	return m.IsRepoFn()
+}
+
+func (m *gitClientMock) LatestTag(ctx context.Context) (string, error) {
+	m.LatestTagFnInvoked++
+	return m.LatestTagFn()
+}
+
+func (m *gitClientMock) AncestorTag(ctx context.Context, include, exclude, branch string) (string, error) {
+	m.AncestorTagFnInvoked++
+	return m.AncestorTagFn(include, exclude, branch)
+}
+
+func (m *gitClientMock) SourceBranch(ctx context.Context, commitHash string) (string, error) {
+	m.SourceBranchFnInvoked++
+	return m.SourceBranchFn(commitHash)
+}
+
+func (m *gitClientMock) Describe(ctx context.Context, include, rev string) (git.Description, error) {
+	m.DescribeFnInvoked++
+	return m.DescribeFn(include, rev)
+}
+
+func (m *gitClientMock) CommitMessage(ctx context.Context, rev string) (string, error) {
+	m.CommitMessageFnInvoked++
+	return m.CommitMessageFn(rev)
+}
+
+func (m *gitClientMock) TagExists(ctx context.Context, tag string) (bool, error) {
+	m.TagExistsFnInvoked++
+	return m.TagExistsFn(tag)
+}
+
+func (m *gitClientMock) IsAncestor(ctx context.Context, ancestor, commit string) (bool, error) {
+	m.IsAncestorFnInvoked++
+	return m.IsAncestorFn(ancestor, commit)
+}
+
+func (m *gitClientMock) Files(ctx context.Context, rev string, match func(path string) bool) (map[string][]byte, error) {
+	m.FilesFnInvoked++
+	return m.FilesFn(rev, match)
+}
+
+func (m *gitClientMock) Commits(ctx context.Context, from, to string) ([]git.Commit, error) {
+	m.CommitsFnInvoked++
+	return m.CommitsFn(from, to)
+}
+
+func (m *gitClientMock) Messages(ctx context.Context, from, to string) ([]git.Message, error) {
+	m.MessagesFnInvoked++
+	return m.MessagesFn(from, to)
+}
+
+func (m *gitClientMock) ChangedFiles(ctx context.Context, from, to string) ([]string, error) {
+	m.ChangedFilesFnInvoked++
+	return m.ChangedFilesFn(from, to)
+}
+
+func newSemVerPtr(t *testing.T, s string) *semver.Version {
+	version, err := semver.New(s)
+	require.NoError(t, err)
+
+	return version
+}
+
+func TestCalculate_BumpType(t *testing.T) {
+	tests := map[string]struct {
+		LatestTag       string
+		SourceBranch    string
+		Bump            string
+		ForcePrerelease bool
+		SemverTag       string
+		BumpType        string
+	}{
+		"feature": {
+			LatestTag:    "v1.2.3",
+			SourceBranch: "feature/some",
+			Bump:         "auto",
+			SemverTag:    "v1.3.0",
+			BumpType:     "minor",
+		},
+		"bugfix": {
+			LatestTag:    "v1.2.3",
+			SourceBranch: "bugfix/some",
+			Bump:         "auto",
+			SemverTag:    "v1.2.4",
+			BumpType:     "patch",
+		},
+		"major": {
+			LatestTag:    "v1.2.3",
+			SourceBranch: "feature/some",
+			Bump:         "major",
+			SemverTag:    "v2.0.0",
+			BumpType:     "major",
+		},
+		"prerelease": {
+			LatestTag:       "v1.3.0-pre.1",
+			SourceBranch:    "bugfix/some",
+			Bump:            "auto",
+			ForcePrerelease: true,
+			SemverTag:       "v1.3.1-pre.1",
+			BumpType:        "patch",
+		},
+		"promote": {
+			LatestTag:    "v1.3.0-pre.2",
+			SourceBranch: "promote/rc",
+			Bump:         "auto",
+			SemverTag:    "v1.3.0-rc.1",
+			BumpType:     "prerelease",
+		},
+		"finalize": {
+			LatestTag:    "v1.3.0-pre.2",
+			SourceBranch: "feature/some",
+			Bump:         "finalize",
+			SemverTag:    "v1.3.0",
+			BumpType:     "finalize",
+		},
+		"docs": {
+			LatestTag:    "v1.2.3",
+			SourceBranch: "docs/some",
+			Bump:         "auto",
+			BumpType:     "none",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:          "81918ffc",
+				Bump:               tc.Bump,
+				Prefix:             "v",
+				PrereleaseID:       "pre",
+				PrereleaseChannels: []string{"pre", "rc"},
+				ForcePrerelease:    tc.ForcePrerelease,
+				BranchName:         "main",
+			}
+
+			gc := initGitClientMock(t, tc.LatestTag, tc.LatestTag, "main", tc.SourceBranch, "81918ffc")
+
+			result, err := versioning.Calculate(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.SemverTag, result.SemverTag)
+			assert.Equal(t, tc.BumpType, result.BumpType)
+			assert.Equal(t, tc.SemverTag != "", result.ShouldRelease())
+		})
+	}
+}
+
+func TestTag_Directives(t *testing.T) {
+	tests := map[string]struct {
+		Bump    string
+		Message string
+		Result  versioning.Result
+		Err     string
+		// CurrentBranch defaults to main.
+		CurrentBranch string
+		Retracted     map[string]string
+	}{
+		"skip release": {
+			Bump:    "auto",
+			Message: "Merge pull request #1 from org/feature/some\n\nUpdate docs [skip release]",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.3",
+				Skipped:     true,
+				Reason:      "release skipped by [skip release] in the commit message",
+				Explain:     []string{"release skipped by [skip release] in the commit message"},
+			},
+		},
+		"no bump": {
+			Bump:    "auto",
+			Message: "Update docs [No Bump] (#1)",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.3",
+				Skipped:     true,
+				Reason:      "release skipped by [No Bump] in the commit message",
+				Explain:     []string{"release skipped by [No Bump] in the commit message"},
+			},
+		},
+		"release as": {
+			Bump:    "auto",
+			Message: "Merge pull request #1 from org/feature/some\n\nDrop v1\n\nRelease-As: 2.0.0",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.3",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v2.0.0",
+				Rule:        "Release-As trailer",
+				Explain:     []string{"Release-As: 2.0.0 in the commit message"},
+			},
+		},
+		"release as prerelease with prefix": {
+			Bump:    "auto",
+			Message: "Merge pull request #1 from org/feature/some\n\nrelease-as: v2.0.0-rc.1",
+			Result: versioning.Result{
+				PreviousTag:  "v1.2.3",
+				AncestorTag:  "v1.2.0",
+				SemverTag:    "v2.0.0-rc.1",
+				IsPrerelease: true,
+				Rule:         "Release-As trailer",
+				Explain:      []string{"Release-As: v2.0.0-rc.1 in the commit message"},
+			},
+		},
+		"release as retracted version": {
+			Bump:      "auto",
+			Message:   "Merge pull request #1 from org/feature/some\n\nRelease-As: 2.0.0",
+			Retracted: map[string]string{"v2.0.0": "broke login"},
+			Result: versioning.Result{
+				PreviousTag: "v1.2.3",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v2.0.1",
+				Rule:        "Release-As trailer",
+				Explain: []string{
+					"Release-As: 2.0.0 in the commit message",
+					"v2.0.0 is retracted, moved to v2.0.1",
+				},
+			},
+		},
+		"release as outside the main branch": {
+			Bump:          "auto",
+			CurrentBranch: "develop",
+			Message:       "Merge pull request #1 from org/feature/some\n\nRelease-As: 2.0.0",
+			Err:           "failed to determine bump strategy: invalid bump strategy",
+		},
+		"release as older version": {
+			Bump:    "auto",
+			Message: "Merge pull request #1 from org/feature/some\n\nRelease-As: 1.2.3",
+			Err:     "version 1.2.3 of Release-As must be greater than the latest tag v1.2.3",
+		},
+		"release as invalid version": {
+			Bump:    "auto",
+			Message: "Merge pull request #1 from org/feature/some\n\nRelease-As: next",
+			Err:     `invalid Release-As version "next": Invalid character(s) found in major number "0next"`,
+		},
+		"explicit bump ignores directives": {
+			Bump:    "minor",
+			Message: "Merge pull request #1 from org/feature/some\n\nUpdate docs [skip release]",
+			Result: versioning.Result{
+				PreviousTag: "v1.2.3",
+				AncestorTag: "v1.2.0",
+				SemverTag:   "v1.3.0",
+				Source:      "feature/some",
+				Rule:        "bump input minor",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         tc.Bump,
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+				Retracted:    tc.Retracted,
+			}
+
+			currentBranch := tc.CurrentBranch
+			if currentBranch == "" {
+				currentBranch = "main"
+			}
+
+			gc := initGitClientMock(t, "v1.2.3", "v1.2.0", currentBranch, "feature/some", "81918ffc")
+			gc.CommitMessageFn = func(rev string) (string, error) {
+				assert.Equal(t, "81918ffc", rev)
+				return tc.Message, nil
+			}
+
+			result, err := versioning.Tag(context.Background(), params, gc)
+			if tc.Err != "" {
+				assert.EqualError(t, err, tc.Err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Result, result)
+
+			if tc.Bump != "auto" {
+				assert.Equal(t, 0, gc.CommitMessageFnInvoked)
+			}
+		})
+	}
+}
+
+func TestTag_Snapshot(t *testing.T) {
+	tests := map[string]struct {
+		Description git.Description
+		Scheme      string
+		Branch      string
+		Source      string
+		Result      versioning.Result
+		Err         string
+	}{
+		"final ancestor": {
+			Description: git.Description{Tag: "v1.2.0", Distance: 14, Hash: "abc1234"},
+			Result: versioning.Result{
+				PreviousTag:  "v1.2.0",
+				AncestorTag:  "v1.2.0",
+				SemverTag:    "v1.2.1-pre.0.14.gabc1234",
+				IsPrerelease: true,
+				Snapshot:     true,
+				Rule:         "bump input snapshot",
+				Explain:      []string{"snapshot of abc1234 at distance 14 from v1.2.0"},
+			},
+		},
+		"prerelease ancestor": {
+			Description: git.Description{Tag: "v1.3.0-pre.2", Distance: 1, Hash: "0123456"},
+			Result: versioning.Result{
+				PreviousTag:  "v1.3.0-pre.2",
+				AncestorTag:  "v1.3.0-pre.2",
+				SemverTag:    "v1.3.0-pre.2.1.g0123456",
+				IsPrerelease: true,
+				Snapshot:     true,
+				Rule:         "bump input snapshot",
+				Explain:      []string{"snapshot of 0123456 at distance 1 from v1.3.0-pre.2"},
+			},
+		},
+		"tagged commit": {
+			Description: git.Description{Tag: "v1.2.0", Hash: "abc1234"},
+			Result: versioning.Result{
+				PreviousTag:  "v1.2.0",
+				AncestorTag:  "v1.2.0",
+				SemverTag:    "v1.2.1-pre.0.0.gabc1234",
+				IsPrerelease: true,
+				Snapshot:     true,
+				Rule:         "bump input snapshot",
+				Explain:      []string{"snapshot of abc1234 at distance 0 from v1.2.0"},
+			},
+		},
+		"no tags": {
+			Description: git.Description{Distance: 3, Hash: "abc1234"},
+			Result: versioning.Result{
+				SemverTag:    "v0.0.1-pre.0.3.gabc1234",
+				IsPrerelease: true,
+				Snapshot:     true,
+				Rule:         "bump input snapshot",
+				Explain:      []string{"snapshot of abc1234 at distance 3 from the first commit"},
+			},
+		},
+		"feature branch": {
+			Description: git.Description{Tag: "v1.5.2", Distance: 2, Hash: "abc1234"},
+			Branch:      "feature/search",
+			Result: versioning.Result{
+				PreviousTag:  "v1.5.2",
+				AncestorTag:  "v1.5.2",
+				SemverTag:    "v1.6.0-pre.0.2.gabc1234",
+				IsPrerelease: true,
+				Snapshot:     true,
+				Rule:         "bump input snapshot",
+				Explain:      []string{"snapshot of abc1234 at distance 2 from v1.5.2"},
+			},
+		},
+		"misc branch": {
+			Description: git.Description{Tag: "v1.5.2", Distance: 2, Hash: "abc1234"},
+			Branch:      "misc/ci",
+			Result: versioning.Result{
+				PreviousTag:  "v1.5.2",
+				AncestorTag:  "v1.5.2",
+				SemverTag:    "v1.5.3-pre.0.2.gabc1234",
+				IsPrerelease: true,
+				Snapshot:     true,
+				Rule:         "bump input snapshot",
+				Explain:      []string{"snapshot of abc1234 at distance 2 from v1.5.2"},
+			},
+		},
+		"merged major branch": {
+			Description: git.Description{Tag: "v1.5.2", Distance: 5, Hash: "abc1234"},
+			Source:      "major/api",
+			Result: versioning.Result{
+				PreviousTag:  "v1.5.2",
+				AncestorTag:  "v1.5.2",
+				SemverTag:    "v2.0.0-pre.0.5.gabc1234",
+				IsPrerelease: true,
+				Snapshot:     true,
+				Rule:         "bump input snapshot",
+				Explain:      []string{"snapshot of abc1234 at distance 5 from v1.5.2"},
+			},
+		},
+		"calendar versioning": {
+			Description: git.Description{Tag: "v2024.1.0", Distance: 2, Hash: "abc1234"},
+			Scheme:      "calver",
+			Err:         "snapshot bump needs the semver scheme",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:    "81918ffc",
+				Bump:         "snapshot",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+				Scheme:       tc.Scheme,
+			}
+
+			branch := tc.Branch
+			if branch == "" {
+				branch = "main"
+			}
+
+			gc := initGitClientMock(t, "v1.2.3", "v1.2.0", branch, tc.Source, "81918ffc")
+			gc.SourceBranchFn = func(commitHash string) (string, error) {
+				if tc.Source == "" {
+					return "", fmt.Errorf("%w: commit message does not contain expected format", git.ErrNoSourceBranch)
+				}
+
+				return tc.Source, nil
+			}
+			gc.DescribeFn = func(include, rev string) (git.Description, error) {
+				assert.Equal(t, "v[0-9]*", include)
+				assert.Equal(t, "81918ffc", rev)
+
+				return tc.Description, nil
+			}
+
+			result, err := versioning.Tag(context.Background(), params, gc)
+			if tc.Err != "" {
+				assert.EqualError(t, err, tc.Err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Result, result)
+			assert.Equal(t, 1, gc.DescribeFnInvoked)
+		})
+	}
+}
+
+func TestTag_Retracted(t *testing.T) {
+	tests := map[string]struct {
+		ForcePrerelease bool
+		Retracted       map[string]string
+		SemverTag       string
+		Explain         []string
+	}{
+		"final version": {
+			Retracted: map[string]string{"v1.3.0": "broke login"},
+			SemverTag: "v1.3.1",
+			Explain:   []string{"v1.3.0 is retracted, moved to v1.3.1"},
+		},
+		"prerelease counter": {
+			ForcePrerelease: true,
+			Retracted:       map[string]string{"v1.3.0-pre.1": "broke login", "v1.3.0-pre.2": "slow search"},
+			SemverTag:       "v1.3.0-pre.3",
+			Explain: []string{
+				"v1.3.0-pre.1 is retracted, moved to v1.3.0-pre.2",
+				"v1.3.0-pre.2 is retracted, moved to v1.3.0-pre.3",
+			},
+		},
+		"other version": {
+			Retracted: map[string]string{"v1.2.0": "broke login"},
+			SemverTag: "v1.3.0",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				CommitSha:       "81918ffc",
+				Bump:            "auto",
+				Prefix:          "v",
+				PrereleaseID:    "pre",
+				ForcePrerelease: tc.ForcePrerelease,
+				BranchName:      "main",
+				Retracted:       tc.Retracted,
+			}
+
+			gc := initGitClientMock(t, "v1.2.3", "v1.2.0", "main", "feature/some", "81918ffc")
+
+			result, err := versioning.Tag(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.SemverTag, result.SemverTag)
+			assert.Equal(t, tc.Explain, result.Explain)
+		})
+	}
+}
+
+func TestCalculate_Retracted(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:    "81918ffc",
+		Bump:         "auto",
+		Prefix:       "v",
+		PrereleaseID: "pre",
+		BranchName:   "main",
+		Retracted:    map[string]string{"v1.3.0": "broke login", "v1.1.0": "slow search", "v9.0.0": "never tagged"},
+	}
+
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.0", "main", "feature/some", "81918ffc")
+	gc.TagExistsFn = func(tag string) (bool, error) {
+		return tag != "v9.0.0", nil
+	}
+
+	result, err := versioning.Calculate(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.1", result.SemverTag)
+	assert.Equal(t, []string{
+		"skipped retracted v1.1.0: slow search",
+		"skipped retracted v1.3.0: broke login",
+		"v1.3.0 is retracted, moved to v1.3.1",
+	}, result.Explain)
+}
+
+func TestTag_ConventionalDetector(t *testing.T) {
+	params := versioning.Params{
+		CommitSha:    "81918ffc",
+		Bump:         "auto",
+		Prefix:       "v",
+		PrereleaseID: "pre",
+		BranchName:   "main",
+
+		ChangeDetectors:    []string{"conventional"},
+		ChangeDetectorMode: "floor",
+		Convention:         conventional.Convention{Types: []string{"feat", "fix"}},
+	}
+
+	gc := initGitClientMock(t, "v1.2.0", "v1.2.0", "main", "bugfix/some", "81918ffc")
+	gc.MessagesFn = func(from, to string) ([]git.Message, error) {
+		assert.Equal(t, "v1.2.0", from)
+		assert.Equal(t, "81918ffc", to)
+
+		return []git.Message{
+			{Hash: "1ad5c3e7", Text: "feat: add search"},
+			{Hash: "0d4e2b17", Text: "refactor!: rename package"},
+		}, nil
+	}
+
+	result, err := versioning.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	// refactor isn't an allowed type, so only the feature counts.
+	assert.Equal(t, "v1.3.0", result.SemverTag)
+	assert.Equal(t, []string{"conventional commits change since v1.2.0: minor"}, result.Explain)
+}
+
+func TestLintCommits(t *testing.T) {
+	tests := map[string]struct {
+		Messages []git.Message
+		Result   versioning.Result
+		Commits  []versioning.CommitProblems
+	}{
+		"valid commits": {
+			Messages: []git.Message{
+				{Hash: "1ad5c3e7", Text: "feat(search): add filters"},
+				{Hash: "0d4e2b17", Text: "fix(search): escape queries\n\nRefs: #12"},
+			},
+			Result: versioning.Result{
+				Explain: []string{"2 commits since origin/main follow the commit convention"},
+			},
+		},
+		"invalid commits": {
+			Messages: []git.Message{
+				{Hash: "1ad5c3e7", Text: "feat: add filters"},
+				{Hash: "0d4e2b17", Text: "fix(search): escape queries"},
+				{Hash: "5f1c7a8e", Text: "WIP\n\nsome notes"},
+			},
+			Commits: []versioning.CommitProblems{
+				{Hash: "1ad5c3e7", Header: "feat: add filters", Problems: []string{"scope is required"}},
+				{Hash: "5f1c7a8e", Header: "WIP", Problems: []string{"header must look like type(scope): description"}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				Mode:       "lint-commits",
+				HeadRef:    "feature/search",
+				BaseRef:    "main",
+				CommitSha:  "81918ffc",
+				Convention: conventional.Convention{Types: conventional.DefaultTypes, RequireScope: true},
+			}
+
+			gc := initGitClientMock(t, "v1.4.2", "v1.4.0", "pull/1/merge", "", "81918ffc")
+			gc.MessagesFn = func(from, to string) ([]git.Message, error) {
+				assert.Equal(t, "origin/main", from)
+				assert.Equal(t, "81918ffc", to)
+
+				return tc.Messages, nil
+			}
+
+			result, err := versioning.LintCommits(context.Background(), params, gc)
+			if tc.Commits != nil {
+				var commitsErr *versioning.CommitsError
+				require.True(t, errors.As(err, &commitsErr))
+
+				assert.Equal(t, tc.Commits, commitsErr.Commits)
+				assert.EqualError(t, err, "2 commit messages break the commit convention")
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Result, result)
+		})
+	}
+}
+
+func TestLintCommits_GitError(t *testing.T) {
+	gc := initGitClientMock(t, "v1.4.2", "v1.4.0", "pull/1/merge", "", "81918ffc")
+	gc.MessagesFn = func(from, to string) ([]git.Message, error) {
+		return nil, errors.New("unknown revision origin/main")
+	}
+
+	_, err := versioning.LintCommits(context.Background(), versioning.Params{BaseRef: "main"}, gc)
+	assert.EqualError(t, err, "failed to list pull request commits: unknown revision origin/main")
+}
+
+func TestLintCommits_BaseNotFetched(t *testing.T) {
+	tests := map[string]struct {
+		Local bool
+		Err   string
+	}{
+		"local base branch": {
+			Local: true,
+		},
+		"shallow checkout": {
+			Err: "base branch main not found, check out the repository with fetch-depth: 0: " +
+				"git log main..81918ffc: exit status 128: fatal: bad revision 'main..81918ffc'",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			var bases []string
+
+			gc := initGitClientMock(t, "v1.4.2", "v1.4.0", "pull/1/merge", "", "81918ffc")
+			gc.MessagesFn = func(from, to string) ([]git.Message, error) {
+				bases = append(bases, from)
+
+				if from == "main" && tc.Local {
+					return []git.Message{{Hash: "1ad5c3e7", Text: "feat: add filters"}}, nil
+				}
+
+				return nil, &git.Error{
+					Args:     []string{"log", from + ".." + to},
+					ExitCode: 128,
+					Stderr:   fmt.Sprintf("fatal: bad revision '%s..%s'", from, to),
+				}
+			}
+
+			params := versioning.Params{BaseRef: "main", CommitSha: "81918ffc", Convention: conventional.Default()}
+
+			result, err := versioning.LintCommits(context.Background(), params, gc)
+			assert.Equal(t, []string{"origin/main", "main"}, bases)
+
+			if tc.Err != "" {
+				assert.EqualError(t, err, tc.Err)
+				assert.ErrorIs(t, err, git.ErrUnknownRevision)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"1 commits since main follow the commit convention"}, result.Explain)
+		})
+	}
+}
+
+func TestValidate_GoModuleRewrite(t *testing.T) {
+	dir := t.TempDir()
+	goMod := filepath.Join(dir, "go.mod")
+	require.NoError(t, os.WriteFile(goMod, []byte("module example.com/x\n"), 0600))
+
+	params := versioning.Params{
+		Mode:            "validate",
+		HeadRef:         "major/some",
+		BaseRef:         "main",
+		CommitSha:       "81918ffc",
+		Bump:            "auto",
+		Prefix:          "v",
+		PrereleaseID:    "pre",
+		BranchName:      "main",
+		RepoDir:         dir,
+		GoModule:        true,
+		GoModuleRewrite: true,
+	}
+
+	gc := initGitClientMock(t, "v1.4.2", "v1.4.0", "pull/1/merge", "", "81918ffc")
+
+	result, err := versioning.Validate(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v2.0.0", result.SemverTag)
+	assert.Contains(t, result.Explain, "go module path must move from example.com/x to example.com/x/v2")
+
+	// The pull request check must not change the work tree.
+	data, err := os.ReadFile(goMod)
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/x\n", string(data))
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		HeadRef string
+		BaseRef string
+		Result  versioning.Result
+		Err     string
+	}{
+		"feature branch into main": {
+			HeadRef: "feature/some",
+			BaseRef: "main",
+			Result: versioning.Result{
+				PreviousTag: "v1.4.2",
+				AncestorTag: "v1.4.0",
+				SemverTag:   "v1.5.0",
+				BumpType:    "minor",
+				Source:      "feature/some",
+				Rule:        "feature/ into main",
+				Formats:     formatter.All(semver.MustParse("1.5.0")),
+			},
+		},
+		"docs branch into main": {
+			HeadRef: "docs/some",
+			BaseRef: "main",
+			Result: versioning.Result{
+				PreviousTag: "v1.4.2",
+				BumpType:    "none",
+				Reason:      "merging docs/some into main doesn't release",
+			},
+		},
+		"invalid branch into main": {
+			HeadRef: "some",
+			BaseRef: "main",
+			Err: `branch "some" matches no branch rule for merges into main, its name must start with ` +
+				"major/, feature/, bugfix/, promote/, doc/, docs/, misc/",
+		},
+		"invalid branch into another branch": {
+			HeadRef: "some",
+			BaseRef: "develop",
+			Result: versioning.Result{
+				PreviousTag: "v1.4.2",
+				BumpType:    "none",
+				Reason:      "pull request into develop doesn't release",
+				Explain:     []string{"pull request into develop doesn't release"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			params := versioning.Params{
+				Mode:         "validate",
+				HeadRef:      tc.HeadRef,
+				BaseRef:      tc.BaseRef,
+				CommitSha:    "81918ffc",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+			}
+
+			gc := initGitClientMock(t, "v1.4.2", "v1.4.0", "pull/1/merge", "", "81918ffc")
+			gc.AncestorTagFn = func(include, exclude, branch string) (string, error) {
+				// The pull request merge commit is checked out.
+				assert.Equal(t, "HEAD", branch)
+				return "v1.4.0", nil
+			}
+
+			result, err := versioning.Validate(context.Background(), params, gc)
+			if tc.Err != "" {
+				assert.EqualError(t, err, tc.Err)
+				assert.ErrorIs(t, err, versioning.ErrInvalidBumpStrategy)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Result, result)
+			assert.Equal(t, 0, gc.SourceBranchFnInvoked)
+			assert.Equal(t, 0, gc.CurrentBranchFnInvoked)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package versioning
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/snapfi/semver-action/pkg/gomod"
+
+	"github.com/blang/semver/v4"
+)
+
+// checkGoModule validates that the major version matches the module path
+// suffix. If rewrite is enabled, a major bump passes, as RewriteGoModule
+// moves the module to the new path.
+func checkGoModule(params Params, version semver.Version) ([]string, error) {
+	path, err := modulePath(params.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gomod.Validate(path, version.Major)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !params.GoModuleRewrite || version.Major <= gomod.Major(path) {
+		return nil, err
+	}
+
+	return []string{fmt.Sprintf("go module path must move from %s to %s", path, gomod.PathForMajor(path, version.Major))}, nil
+}
+
+// RewriteGoModule rewrites go.mod and internal imports to the module path of
+// the released major version, if rewrite is enabled and the major version was
+// bumped. It changes the work tree, so only the generate mode runs it.
+func RewriteGoModule(params Params, result Result) ([]string, error) {
+	if !params.GoModule || !params.GoModuleRewrite || !result.ShouldRelease() {
+		return nil, nil
+	}
+
+	scheme, err := newScheme(params)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := scheme.Parse(strings.TrimPrefix(result.SemverTag, params.Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generated tag %q: %w", result.SemverTag, err)
+	}
+
+	path, err := modulePath(params.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if gomod.Validate(path, version.Major) == nil || version.Major <= gomod.Major(path) {
+		return nil, nil
+	}
+
+	newPath := gomod.PathForMajor(path, version.Major)
+
+	if err := gomod.Rewrite(params.RepoDir, path, newPath); err != nil {
+		return nil, fmt.Errorf("failed to rewrite module path to %q: %w", newPath, err)
+	}
+
+	return []string{fmt.Sprintf("go module path rewritten from %s to %s", path, newPath)}, nil
+}
+
+// modulePath returns the module path declared in the go.mod of dir.
+func modulePath(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	path, err := gomod.ModulePath(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,170 @@
+package versioning
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/snapfi/semver-action/pkg/git"
+
+	"github.com/apex/log"
+	"github.com/blang/semver/v4"
+)
+
+// historyReader reads the version tags of a branch.
+type historyReader interface {
+	MakeSafe(ctx context.Context) error
+	MergedTags(ctx context.Context, rev, pattern string) ([]git.Tag, error)
+	TagMetadata(ctx context.Context, tag string) (git.Metadata, error)
+	SourceBranch(ctx context.Context, commitHash string) (string, error)
+}
+
+// Release is a version tag in the history of a branch.
+type Release struct {
+	Tag    string    `json:"tag"`
+	Commit string    `json:"commit"`
+	Date   time.Time `json:"date"`
+	// Bump is the highest part of the version changed from the previous
+	// release, or from 0.0.0 for the first one.
+	Bump       string `json:"bump"`
+	Prerelease bool   `json:"prerelease"`
+	// Source is the merged branch that produced the release, empty if it
+	// can't be found.
+	Source string `json:"source"`
+}
+
+// History returns the version tags reachable from rev in precedence order.
+// Tags that aren't versions of the scheme are left out. The source branch is
+// read from the version metadata, else from the message of the tagged commit.
+func History(ctx context.Context, params Params, r historyReader, rev string) ([]Release, error) {
+	scheme, err := newScheme(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.MakeSafe(ctx); err != nil {
+		return nil, fmt.Errorf("failed to make safe: %w", err)
+	}
+
+	tags, err := r.MergedTags(ctx, rev, params.Prefix+"[0-9]*")
+	if err != nil {
+		return nil, err
+	}
+
+	type version struct {
+		tag     git.Tag
+		version semver.Version
+	}
+
+	versions := make([]version, 0, len(tags))
+
+	for _, tag := range tags {
+		parsed, err := scheme.Parse(strings.TrimPrefix(tag.Name, params.Prefix))
+		if err != nil {
+			log.Debugf("skipping tag %s: %s", tag.Name, err)
+
+			continue
+		}
+
+		versions = append(versions, version{tag: tag, version: parsed})
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].version.LT(versions[j].version)
+	})
+
+	previous := semver.MustParse(tagDefault)
+	releases := make([]Release, 0, len(versions))
+
+	for _, v := range versions {
+		source, err := releaseSource(ctx, r, v.tag)
+		if err != nil {
+			return nil, err
+		}
+
+		releases = append(releases, Release{
+			Tag:        v.tag.Name,
+			Commit:     v.tag.Commit,
+			Date:       v.tag.Date,
+			Bump:       bumpType(previous, v.version),
+			Prerelease: len(v.version.Pre) > 0,
+			Source:     source,
+		})
+
+		previous = v.version
+	}
+
+	return releases, nil
+}
+
+// releaseSource returns the merged branch that produced tag, empty if it
+// can't be found.
+func releaseSource(ctx context.Context, r historyReader, tag git.Tag) (string, error) {
+	metadata, err := r.TagMetadata(ctx, tag.Name)
+	if err == nil && metadata.Source != "" {
+		return metadata.Source, nil
+	}
+
+	if err != nil && !errors.Is(err, git.ErrNoMetadata) {
+		return "", err
+	}
+
+	source, err := r.SourceBranch(ctx, tag.Commit)
+	if errors.Is(err, git.ErrNoSourceBranch) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get source branch of %s: %w", tag.Name, err)
+	}
+
+	return source, nil
+}
+
+// WriteHistory renders releases to w as a table, json or csv.
+func WriteHistory(w io.Writer, format string, releases []Release) error {
+	switch format {
+	case "", "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "TAG\tCOMMIT\tDATE\tBUMP\tSTATUS\tSOURCE")
+
+		for _, r := range releases {
+			status := "final"
+			if r.Prerelease {
+				status = "prerelease"
+			}
+
+			fmt.Fprintf(tw, "%s\t%.7s\t%s\t%s\t%s\t%s\n", r.Tag, r.Commit, r.Date.Format("2006-01-02"), r.Bump, status, r.Source)
+		}
+
+		return tw.Flush()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(releases)
+	case "csv":
+		cw := csv.NewWriter(w)
+
+		_ = cw.Write([]string{"tag", "commit", "date", "bump", "prerelease", "source"})
+
+		for _, r := range releases {
+			_ = cw.Write([]string{r.Tag, r.Commit, r.Date.Format(time.RFC3339), r.Bump, strconv.FormatBool(r.Prerelease), r.Source})
+		}
+
+		cw.Flush()
+
+		return cw.Error()
+	default:
+		return fmt.Errorf("invalid history format: %s", format)
+	}
+}
@@ -0,0 +1,142 @@
+package versioning_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/snapfi/semver-action/internal/versioning"
+	"github.com/snapfi/semver-action/pkg/git"
+	"github.com/snapfi/semver-action/pkg/gittest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory_Integration(t *testing.T) {
+	r := gittest.New(t)
+	releaseHistory(r)
+	r.Tag("latest")
+
+	// Squash merges leave no source in the message, the metadata has it.
+	r.Branch("feature/search")
+	r.Commit("add search")
+	r.Checkout("main")
+	squash := r.Squash("feature/search", "add search (#3)")
+
+	ctx := context.Background()
+	gc := git.NewGit(r.Dir)
+	metadata := git.Metadata{Version: "v1.1.0", Previous: "v1.1.0-pre.1", Bump: "minor", Source: "feature/search"}
+	require.NoError(t, gc.AnnotateTag(ctx, "v1.1.0", "HEAD", "Release v1.1.0\n\n"+metadata.String()))
+
+	// Tags of unmerged branches aren't in the history of main.
+	r.Checkout("feature/search")
+	r.Tag("v2.0.0-pre.1")
+	r.Checkout("main")
+
+	releases, err := versioning.History(ctx, versioning.Params{Prefix: "v"}, gc, "main")
+	require.NoError(t, err)
+
+	for i, release := range releases {
+		assert.False(t, release.Date.IsZero(), release.Tag)
+
+		if i > 0 {
+			assert.True(t, release.Date.After(releases[i-1].Date), release.Tag)
+		}
+
+		releases[i].Date = time.Time{}
+	}
+
+	assert.Equal(t, []versioning.Release{
+		{Tag: "v1.0.0", Commit: r.Git("rev-parse", "v1.0.0^{commit}"), Bump: "major"},
+		{Tag: "v1.0.1", Commit: r.Git("rev-parse", "v1.0.1^{commit}"), Bump: "patch", Source: "bugfix/crash"},
+		{Tag: "v1.1.0-pre.1", Commit: r.Git("rev-parse", "v1.1.0-pre.1"), Bump: "minor", Prerelease: true, Source: "feature/login"},
+		{Tag: "v1.1.0", Commit: squash, Bump: "finalize", Source: "feature/search"},
+	}, releases)
+}
+
+func TestHistory_OtherOwner(t *testing.T) {
+	r := gittest.New(t)
+	releaseHistory(r)
+
+	// Git refuses repositories owned by another user, e.g. the checkout of a
+	// container action, unless they are a safe.directory.
+	t.Setenv("GIT_TEST_ASSUME_DIFFERENT_OWNER", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	releases, err := versioning.History(context.Background(), versioning.Params{Prefix: "v"}, git.NewGit(r.Dir), "main")
+	require.NoError(t, err)
+
+	tags := make([]string, 0, len(releases))
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+
+	assert.Equal(t, []string{"v1.0.0", "v1.0.1", "v1.1.0-pre.1"}, tags)
+}
+
+func TestHistory_UnknownBranch(t *testing.T) {
+	r := gittest.New(t)
+	r.Commit("initial commit")
+
+	_, err := versioning.History(context.Background(), versioning.Params{Prefix: "v"}, git.NewGit(r.Dir), "missing")
+	assert.ErrorIs(t, err, git.ErrUnknownRevision)
+}
+
+func TestWriteHistory(t *testing.T) {
+	releases := []versioning.Release{
+		{Tag: "v1.0.0", Commit: "81918ffc2d", Date: time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC), Bump: "major"},
+		{
+			Tag: "v1.1.0-pre.1", Commit: "1ad5c3e7a0", Date: time.Date(2023, 11, 15, 9, 0, 0, 0, time.UTC),
+			Bump: "minor", Prerelease: true, Source: "feature/login",
+		},
+	}
+
+	tests := map[string]string{
+		"table": "TAG           COMMIT   DATE        BUMP   STATUS      SOURCE\n" +
+			"v1.0.0        81918ff  2023-11-14  major  final       \n" +
+			"v1.1.0-pre.1  1ad5c3e  2023-11-15  minor  prerelease  feature/login\n",
+		"csv": "tag,commit,date,bump,prerelease,source\n" +
+			"v1.0.0,81918ffc2d,2023-11-14T22:13:20Z,major,false,\n" +
+			"v1.1.0-pre.1,1ad5c3e7a0,2023-11-15T09:00:00Z,minor,true,feature/login\n",
+		"json": `[
+  {
+    "tag": "v1.0.0",
+    "commit": "81918ffc2d",
+    "date": "2023-11-14T22:13:20Z",
+    "bump": "major",
+    "prerelease": false,
+    "source": ""
+  },
+  {
+    "tag": "v1.1.0-pre.1",
+    "commit": "1ad5c3e7a0",
+    "date": "2023-11-15T09:00:00Z",
+    "bump": "minor",
+    "prerelease": true,
+    "source": "feature/login"
+  }
+]
+`,
+	}
+
+	for format, expected := range tests {
+		format, expected := format, expected
+
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			require.NoError(t, versioning.WriteHistory(&buf, format, releases))
+
+			assert.Equal(t, expected, buf.String())
+		})
+	}
+}
+
+func TestWriteHistory_InvalidFormat(t *testing.T) {
+	err := versioning.WriteHistory(&bytes.Buffer{}, "xml", nil)
+	assert.EqualError(t, err, "invalid history format: xml")
+}
@@ -0,0 +1,655 @@
+package versioning_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/snapfi/semver-action/internal/versioning"
+	"github.com/snapfi/semver-action/pkg/conventional"
+	"github.com/snapfi/semver-action/pkg/git"
+	"github.com/snapfi/semver-action/pkg/gittest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// releaseHistory creates a history on main with two final releases, a
+// prerelease and a merged feature branch waiting to be released.
+func releaseHistory(r *gittest.Repo) {
+	r.Commit("initial commit")
+	r.Tag("v1.0.0")
+
+	r.Branch("bugfix/crash")
+	r.Commit("fix crash")
+	r.Checkout("main")
+	r.MergePR(1, "bugfix/crash")
+	r.AnnotatedTag("v1.0.1", "release v1.0.1")
+
+	r.Branch("feature/login")
+	r.Commit("add login")
+	r.Checkout("main")
+	r.MergePR(2, "feature/login")
+	r.Tag("v1.1.0-pre.1")
+}
+
+// forEachClient runs test with the git client of r and a snapshot of it,
+// which must give the same answers.
+func forEachClient(t *testing.T, r *gittest.Repo, test func(t *testing.T, gc versioning.Repository)) {
+	t.Helper()
+
+	t.Run("client", func(t *testing.T) {
+		test(t, git.NewGit(r.Dir))
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		test(t, git.NewSnapshot(git.NewGit(r.Dir)))
+	})
+}
+
+func TestTag_Integration(t *testing.T) {
+	tests := map[string]struct {
+		Setup  func(r *gittest.Repo)
+		Params versioning.Params
+		Result versioning.Result
+	}{
+		"feature merged into main": {
+			Setup: func(r *gittest.Repo) {
+				releaseHistory(r)
+
+				r.Branch("feature/search")
+				r.Commit("add search")
+				r.Checkout("main")
+				r.MergePR(3, "feature/search")
+			},
+			Params: versioning.Params{
+				CommitSha:    "HEAD",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v1.1.0-pre.1",
+				AncestorTag: "v1.0.1",
+				SemverTag:   "v1.2.0",
+				Source:      "feature/search",
+				Rule:        "feature/ into main",
+			},
+		},
+		"bugfix merged into main": {
+			Setup: func(r *gittest.Repo) {
+				r.Commit("initial commit")
+				r.AnnotatedTag("v1.0.0", "release v1.0.0")
+
+				r.Branch("bugfix/typo")
+				r.Commit("fix typo")
+				r.Checkout("main")
+				r.MergePR(1, "bugfix/typo")
+			},
+			Params: versioning.Params{
+				CommitSha:    "HEAD",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v1.0.0",
+				AncestorTag: "v1.0.0",
+				SemverTag:   "v1.0.1",
+				Source:      "bugfix/typo",
+				Rule:        "bugfix/ into main",
+			},
+		},
+		"bugfix build on main": {
+			Setup: func(r *gittest.Repo) {
+				releaseHistory(r)
+
+				r.Branch("bugfix/logout")
+				r.Commit("fix logout")
+				r.Checkout("main")
+				r.MergePR(3, "bugfix/logout")
+			},
+			Params: versioning.Params{
+				CommitSha:       "HEAD",
+				Bump:            "auto",
+				Prefix:          "v",
+				PrereleaseID:    "pre",
+				ForcePrerelease: true,
+				BranchName:      "main",
+			},
+			Result: versioning.Result{
+				PreviousTag:  "v1.1.0-pre.1",
+				AncestorTag:  "v1.1.0-pre.1",
+				SemverTag:    "v1.1.1-pre.1",
+				IsPrerelease: true,
+				Source:       "bugfix/logout",
+				Rule:         "bugfix/ into main",
+			},
+		},
+		"tags not matching the prefix": {
+			Setup: func(r *gittest.Repo) {
+				r.Commit("initial commit")
+				r.Tag("release-1.0.0")
+				r.Commit("update docs")
+				r.Tag("v9.0.0")
+				r.Tag("release-notes")
+
+				r.Branch("feature/search")
+				r.Commit("add search")
+				r.Checkout("main")
+				r.MergePR(1, "feature/search")
+				r.Tag("release-1.1.0-rc")
+			},
+			Params: versioning.Params{
+				CommitSha:    "HEAD",
+				Bump:         "auto",
+				Prefix:       "release-",
+				PrereleaseID: "rc",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "release-1.1.0-rc",
+				AncestorTag: "release-1.0.0",
+				SemverTag:   "release-1.2.0",
+				Source:      "feature/search",
+				Rule:        "feature/ into main",
+			},
+		},
+		"finalize prerelease": {
+			Setup: func(r *gittest.Repo) {
+				releaseHistory(r)
+
+				r.Branch("misc/release")
+				r.Commit("update changelog")
+				r.Checkout("main")
+				r.MergePR(3, "misc/release")
+			},
+			Params: versioning.Params{
+				CommitSha:    "HEAD",
+				Bump:         "finalize",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v1.1.0-pre.1",
+				AncestorTag: "v1.0.1",
+				SemverTag:   "v1.1.0",
+				Source:      "misc/release",
+				Rule:        "bump input finalize",
+			},
+		},
+		"docs merged into main": {
+			Setup: func(r *gittest.Repo) {
+				releaseHistory(r)
+
+				r.Branch("docs/readme")
+				r.Commit("update readme")
+				r.Checkout("main")
+				r.MergePR(3, "docs/readme")
+			},
+			Params: versioning.Params{
+				CommitSha:    "HEAD",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v1.1.0-pre.1",
+				Reason:      "merging docs/readme into main doesn't release",
+			},
+		},
+		"feature branched before a hotfix": {
+			Setup: func(r *gittest.Repo) {
+				releaseHistory(r)
+
+				r.Branch("feature/report")
+				r.Commit("start report")
+				r.Checkout("main")
+				r.Commit("hotfix")
+				r.Tag("v1.0.2")
+				r.Checkout("feature/report")
+				r.Commit("finish report")
+				r.Checkout("main")
+				r.MergePR(4, "feature/report")
+			},
+			Params: versioning.Params{
+				CommitSha:    "HEAD",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+			},
+			Result: versioning.Result{
+				PreviousTag: "v1.0.2",
+				AncestorTag: "v1.0.2",
+				SemverTag:   "v1.1.0",
+				Source:      "feature/report",
+				Rule:        "feature/ into main",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			r := gittest.New(t)
+			tc.Setup(r)
+
+			forEachClient(t, r, func(t *testing.T, gc versioning.Repository) {
+				result, err := versioning.Tag(context.Background(), tc.Params, gc)
+				require.NoError(t, err)
+
+				assert.Equal(t, tc.Result, result)
+			})
+		})
+	}
+}
+
+func TestTag_IntegrationFirstRelease(t *testing.T) {
+	r := gittest.New(t)
+	root := r.Commit("initial commit")
+
+	r.Branch("feature/init")
+	r.Commit("add project")
+	r.Checkout("main")
+	r.MergePR(1, "feature/init")
+
+	params := versioning.Params{CommitSha: "HEAD", Bump: "auto", Prefix: "v", PrereleaseID: "pre", BranchName: "main"}
+
+	forEachClient(t, r, func(t *testing.T, gc versioning.Repository) {
+		result, err := versioning.Tag(context.Background(), params, gc)
+		require.NoError(t, err)
+
+		// Without tags, the root commit is the ancestor.
+		assert.Equal(t, versioning.Result{
+			AncestorTag: root,
+			SemverTag:   "v0.1.0",
+			Source:      "feature/init",
+			Rule:        "feature/ into main",
+		}, result)
+	})
+}
+
+func TestTag_IntegrationShallowClone(t *testing.T) {
+	r := gittest.New(t)
+	releaseHistory(r)
+
+	r.Branch("feature/search")
+	r.Commit("add search")
+	r.Checkout("main")
+	r.MergePR(3, "feature/search")
+
+	params := versioning.Params{CommitSha: "HEAD", Bump: "auto", Prefix: "v", PrereleaseID: "pre", BranchName: "main"}
+
+	// Tags outside a shallow clone are unknown, so the version restarts as if
+	// the repository had none, which is why checkouts need fetch-depth: 0.
+	t.Run("depth 1", func(t *testing.T) {
+		clone := r.Clone(1)
+
+		forEachClient(t, clone, func(t *testing.T, gc versioning.Repository) {
+			result, err := versioning.Tag(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, versioning.Result{
+				AncestorTag: clone.Head(),
+				SemverTag:   "v0.1.0",
+				Source:      "feature/search",
+				Rule:        "feature/ into main",
+			}, result)
+		})
+	})
+
+	t.Run("full history", func(t *testing.T) {
+		forEachClient(t, r.Clone(0), func(t *testing.T, gc versioning.Repository) {
+			result, err := versioning.Tag(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, versioning.Result{
+				PreviousTag: "v1.1.0-pre.1",
+				AncestorTag: "v1.0.1",
+				SemverTag:   "v1.2.0",
+				Source:      "feature/search",
+				Rule:        "feature/ into main",
+			}, result)
+		})
+	})
+}
+
+func TestTag_IntegrationSquashMerge(t *testing.T) {
+	r := gittest.New(t)
+	releaseHistory(r)
+
+	r.Branch("feature/search")
+	r.Commit("add search")
+	r.Checkout("main")
+	r.Squash("feature/search", "Add search (#3)")
+
+	params := versioning.Params{CommitSha: "HEAD", Bump: "auto", Prefix: "v", PrereleaseID: "pre", BranchName: "main"}
+
+	forEachClient(t, r, func(t *testing.T, gc versioning.Repository) {
+		_, err := versioning.Tag(context.Background(), params, gc)
+
+		// Squash merges don't name the source branch.
+		assert.ErrorIs(t, err, git.ErrNoSourceBranch)
+	})
+}
+
+func TestTag_IntegrationSnapshot(t *testing.T) {
+	r := gittest.New(t)
+	releaseHistory(r)
+
+	r.Commit("update readme")
+	r.Commit("update changelog")
+
+	params := versioning.Params{CommitSha: "HEAD", Bump: "snapshot", Prefix: "v", PrereleaseID: "pre", BranchName: "main"}
+	hash := r.Git("rev-parse", "--short=7", "HEAD")
+
+	forEachClient(t, r, func(t *testing.T, gc versioning.Repository) {
+		// Snapshots don't create tags, so every run gives the same version.
+		for i := 0; i < 2; i++ {
+			result, err := versioning.Tag(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, "v1.1.0-pre.1.2.g"+hash, result.SemverTag)
+			assert.Equal(t, "v1.1.0-pre.1", result.AncestorTag)
+		}
+	})
+}
+
+func TestCalculate_IntegrationRetracted(t *testing.T) {
+	tests := map[string]struct {
+		Retract   func(r *gittest.Repo)
+		Retracted map[string]string
+		Reason    string
+	}{
+		"tag annotation": {
+			Retract: func(r *gittest.Repo) {
+				r.AnnotatedTag("v1.1.0", "Release v1.1.0\n\nSemver-Version: v1.1.0\nSemver-Retracted: broke login")
+			},
+			Reason: "broke login",
+		},
+		"note": {
+			Retract: func(r *gittest.Repo) {
+				r.Tag("v1.1.0")
+				r.Git("notes", "--ref=semver", "add", "-m", "Semver-Version: v1.1.0\nSemver-Retracted: broke login")
+			},
+			Reason: "broke login",
+		},
+		"configuration": {
+			Retract: func(r *gittest.Repo) {
+				r.Tag("v1.1.0")
+			},
+			Retracted: map[string]string{"v1.1.0": "listed in retracted_versions"},
+			Reason:    "listed in retracted_versions",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			r := gittest.New(t)
+			r.Commit("initial commit")
+			r.Tag("v1.0.0")
+
+			r.Branch("feature/login")
+			r.Commit("add login")
+			r.Checkout("main")
+			r.MergePR(1, "feature/login")
+			tc.Retract(r)
+
+			r.Branch("feature/search")
+			r.Commit("add search")
+			r.Checkout("main")
+			r.MergePR(2, "feature/search")
+
+			for _, snapshot := range []bool{false, true} {
+				ctx := context.Background()
+				gc := git.NewGit(r.Dir)
+				params := versioning.Params{
+					CommitSha:    "HEAD",
+					Bump:         "auto",
+					Prefix:       "v",
+					PrereleaseID: "pre",
+					BranchName:   "main",
+					Retracted:    tc.Retracted,
+				}
+
+				require.NoError(t, versioning.Retract(ctx, &params, gc))
+
+				var client versioning.Repository = gc
+				if snapshot {
+					client = git.NewSnapshot(gc)
+				}
+
+				result, err := versioning.Calculate(ctx, params, client)
+				require.NoError(t, err)
+
+				// The retracted minor isn't released again.
+				assert.Equal(t, "v1.0.0", result.PreviousTag)
+				assert.Equal(t, "v1.0.0", result.AncestorTag)
+				assert.Equal(t, "v1.1.1", result.SemverTag)
+				assert.Equal(t, []string{
+					"skipped retracted v1.1.0: " + tc.Reason,
+					"v1.1.0 is retracted, moved to v1.1.1",
+				}, result.Explain)
+			}
+		})
+	}
+}
+
+func TestRecordMetadata_Integration(t *testing.T) {
+	for _, metadata := range []string{"annotation", "notes"} {
+		metadata := metadata
+
+		t.Run(metadata, func(t *testing.T) {
+			r := gittest.New(t)
+			releaseHistory(r)
+
+			r.Branch("feature/search")
+			r.Commit("add search")
+			r.Checkout("main")
+			r.MergePR(3, "feature/search")
+
+			ctx := context.Background()
+			gc := git.NewGit(r.Dir)
+			params := versioning.Params{
+				CommitSha:    "HEAD",
+				Bump:         "auto",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+				Metadata:     metadata,
+			}
+
+			result, err := versioning.Calculate(ctx, params, gc)
+			require.NoError(t, err)
+
+			require.NoError(t, versioning.RecordMetadata(ctx, params, gc, result))
+
+			// Notes don't create the tag, the workflow does.
+			if metadata == "notes" {
+				r.Tag(result.SemverTag)
+			}
+
+			m, err := gc.TagMetadata(ctx, "v1.2.0")
+			require.NoError(t, err)
+
+			assert.Equal(t, git.Metadata{
+				Version:  "v1.2.0",
+				Previous: "v1.1.0-pre.1",
+				Bump:     "minor",
+				Source:   "feature/search",
+				Rule:     "feature/ into main",
+			}, m)
+		})
+	}
+}
+
+func TestRecordMetadata_Rerun(t *testing.T) {
+	r := gittest.New(t)
+	releaseHistory(r)
+
+	r.Branch("feature/search")
+	r.Commit("add search")
+	r.Checkout("main")
+	r.MergePR(3, "feature/search")
+
+	ctx := context.Background()
+	gc := git.NewGit(r.Dir)
+	params := versioning.Params{
+		CommitSha:    "HEAD",
+		Bump:         "auto",
+		Prefix:       "v",
+		PrereleaseID: "pre",
+		BranchName:   "main",
+		Metadata:     "annotation",
+	}
+
+	result, err := versioning.Calculate(ctx, params, gc)
+	require.NoError(t, err)
+
+	require.NoError(t, versioning.RecordMetadata(ctx, params, gc, result))
+
+	message := r.Git("tag", "--list", "--format=%(contents)", result.SemverTag)
+
+	err = versioning.RecordMetadata(ctx, params, gc, result)
+	require.ErrorIs(t, err, versioning.ErrVersionExists)
+
+	assert.EqualError(t, err, "version already exists: v1.2.0")
+	assert.Equal(t, message, r.Git("tag", "--list", "--format=%(contents)", result.SemverTag))
+}
+
+func TestRecordMetadata_NoRelease(t *testing.T) {
+	r := gittest.New(t)
+	r.Commit("initial commit")
+
+	gc := git.NewGit(r.Dir)
+	params := versioning.Params{CommitSha: "HEAD", Metadata: "annotation"}
+
+	err := versioning.RecordMetadata(context.Background(), params, gc, versioning.Result{PreviousTag: "v1.0.0"})
+	require.NoError(t, err)
+
+	assert.Empty(t, r.Git("tag", "--list"))
+}
+
+func TestRecordMetadata_Snapshot(t *testing.T) {
+	for _, metadata := range []string{"annotation", "notes"} {
+		metadata := metadata
+
+		t.Run(metadata, func(t *testing.T) {
+			r := gittest.New(t)
+			releaseHistory(r)
+			r.Commit("work in progress")
+
+			ctx := context.Background()
+			gc := git.NewGit(r.Dir)
+			params := versioning.Params{
+				CommitSha:    "HEAD",
+				Bump:         "snapshot",
+				Scheme:       "semver",
+				Prefix:       "v",
+				PrereleaseID: "pre",
+				BranchName:   "main",
+				Metadata:     metadata,
+			}
+
+			tags := r.Git("tag", "--list")
+
+			result, err := versioning.Calculate(ctx, params, gc)
+			require.NoError(t, err)
+			require.True(t, result.Snapshot)
+
+			require.NoError(t, versioning.RecordMetadata(ctx, params, gc, result))
+
+			assert.Equal(t, tags, r.Git("tag", "--list"))
+			assert.Empty(t, r.Git("notes", "--ref="+git.NotesRef, "list"))
+		})
+	}
+}
+
+func TestValidate_Integration(t *testing.T) {
+	r := gittest.New(t)
+	releaseHistory(r)
+
+	r.Branch("feature/search")
+	r.Commit("add search")
+
+	// Pull request checkouts are the detached merge commit, without main.
+	r.Checkout("main")
+	r.Git("checkout", "--quiet", "--detach")
+	merge := r.Merge("feature/search", "Merge 5f1c7a8 into 0d4e2b1")
+	clone := r.Clone(0)
+
+	require.Empty(t, clone.Git("branch", "--list", "main"))
+	require.Equal(t, merge, clone.Head())
+
+	params := versioning.Params{
+		Mode:         "validate",
+		HeadRef:      "feature/search",
+		BaseRef:      "main",
+		CommitSha:    merge,
+		Bump:         "auto",
+		Prefix:       "v",
+		PrereleaseID: "pre",
+		BranchName:   "main",
+	}
+
+	forEachClient(t, clone, func(t *testing.T, gc versioning.Repository) {
+		result, err := versioning.Validate(context.Background(), params, gc)
+		require.NoError(t, err)
+
+		assert.Equal(t, "v1.1.0-pre.1", result.PreviousTag)
+		assert.Equal(t, "v1.0.1", result.AncestorTag)
+		assert.Equal(t, "v1.2.0", result.SemverTag)
+	})
+
+	params.HeadRef = "search"
+
+	forEachClient(t, clone, func(t *testing.T, gc versioning.Repository) {
+		_, err := versioning.Validate(context.Background(), params, gc)
+
+		var branchErr *versioning.BranchError
+		require.True(t, errors.As(err, &branchErr))
+
+		assert.Equal(t, "search", branchErr.Branch)
+	})
+}
+
+func TestLintCommits_Integration(t *testing.T) {
+	r := gittest.New(t)
+	releaseHistory(r)
+
+	r.Branch("feature/search")
+	r.Commit("feat(search): add filters")
+	r.Commit("Fix typo")
+
+	r.Checkout("main")
+	r.Git("checkout", "--quiet", "--detach")
+	merge := r.Merge("feature/search", "Merge 5f1c7a8 into 0d4e2b1")
+	clone := r.Clone(0)
+
+	params := versioning.Params{
+		Mode:       "lint-commits",
+		HeadRef:    "feature/search",
+		BaseRef:    "main",
+		CommitSha:  merge,
+		Convention: conventional.Default(),
+	}
+
+	forEachClient(t, clone, func(t *testing.T, gc versioning.Repository) {
+		_, err := versioning.LintCommits(context.Background(), params, gc)
+
+		// The merge commit isn't linted.
+		var commitsErr *versioning.CommitsError
+		require.True(t, errors.As(err, &commitsErr))
+		require.Len(t, commitsErr.Commits, 1)
+
+		assert.Equal(t, "Fix typo", commitsErr.Commits[0].Header)
+	})
+}
@@ -0,0 +1,61 @@
+package versioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/snapfi/semver-action/pkg/git"
+)
+
+// LintCommits checks the messages of the commits of a pull request, between
+// the base branch and the commit, against the commit convention. It returns a
+// CommitsError listing the commits breaking it. The convention is the one of
+// the conventional change detector, so lint and bumps agree. The base branch
+// is read from origin, or the local branch if it wasn't fetched, so shallow
+// checkouts without either fail.
+func LintCommits(ctx context.Context, params Params, gc Repository) (Result, error) {
+	base := "origin/" + params.BaseRef
+
+	head := params.CommitSha
+	if head == "" {
+		head = "HEAD"
+	}
+
+	messages, err := gc.Messages(ctx, base, head)
+	if errors.Is(err, git.ErrUnknownRevision) {
+		base = params.BaseRef
+		messages, err = gc.Messages(ctx, base, head)
+	}
+
+	if errors.Is(err, git.ErrUnknownRevision) {
+		return Result{}, fmt.Errorf("base branch %s not found, check out the repository with fetch-depth: 0: %w", params.BaseRef, err)
+	}
+
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to list pull request commits: %w", err)
+	}
+
+	lintErr := &CommitsError{}
+
+	for _, message := range messages {
+		if problems := params.Convention.Lint(message.Text); len(problems) > 0 {
+			header, _, _ := strings.Cut(message.Text, "\n")
+
+			lintErr.Commits = append(lintErr.Commits, CommitProblems{
+				Hash:     message.Hash,
+				Header:   header,
+				Problems: problems,
+			})
+		}
+	}
+
+	if len(lintErr.Commits) > 0 {
+		return Result{}, lintErr
+	}
+
+	return Result{
+		Explain: []string{fmt.Sprintf("%d commits since %s follow the commit convention", len(messages), base)},
+	}, nil
+}
@@ -0,0 +1,59 @@
+package versioning
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/snapfi/semver-action/pkg/git"
+)
+
+// metadataWriter stores version metadata in the repository.
+type metadataWriter interface {
+	TagExists(ctx context.Context, tag string) (bool, error)
+	AnnotateTag(ctx context.Context, tag, rev, message string) error
+	AddNote(ctx context.Context, rev, message string) error
+}
+
+// RecordMetadata stores how the released version was calculated, as set by
+// the metadata param: in the message of the version tag, created annotated,
+// or in a note of the commit. Nothing is written if no version is released or
+// if the version is a snapshot. An annotation fails with ErrVersionExists if
+// the version is tagged already, e.g. by a rerun.
+func RecordMetadata(ctx context.Context, params Params, w metadataWriter, result Result) error {
+	if params.Metadata == "" || params.Metadata == "none" || !result.ShouldRelease() || result.Snapshot {
+		return nil
+	}
+
+	rev := params.CommitSha
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	metadata := git.Metadata{
+		Version:  result.SemverTag,
+		Previous: result.PreviousTag,
+		Bump:     result.BumpType,
+		Source:   result.Source,
+		Rule:     result.Rule,
+		Explain:  strings.Join(result.Explain, "; "),
+	}
+
+	switch params.Metadata {
+	case "annotation":
+		exists, err := w.TagExists(ctx, result.SemverTag)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return fmt.Errorf("%w: %s", ErrVersionExists, result.SemverTag)
+		}
+
+		return w.AnnotateTag(ctx, result.SemverTag, rev, fmt.Sprintf("Release %s\n\n%s", result.SemverTag, metadata))
+	case "notes":
+		return w.AddNote(ctx, rev, metadata.String())
+	default:
+		return fmt.Errorf("invalid metadata value: %s", params.Metadata)
+	}
+}
@@ -0,0 +1,180 @@
+package versioning
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapfi/semver-action/pkg/calver"
+	"github.com/snapfi/semver-action/pkg/conventional"
+	"github.com/snapfi/semver-action/pkg/detect"
+
+	"github.com/blang/semver/v4"
+)
+
+var (
+	// nolint
+	validSchemes = []string{"semver", "calver"}
+	// nolint
+	validChangeDetectorModes = []string{"floor", "override"}
+	// nolint
+	validBumpStrategies = []string{"auto", "major", "minor", "patch", "promote", "finalize", "graduate", "snapshot"}
+	// nolint
+	validModes = []string{"generate", "validate", "lint-commits"}
+	// nolint
+	validMetadata = []string{"none", "annotation", "notes"}
+)
+
+// Params contains the version calculation parameters. HeadRef and BaseRef
+// are the branches of the pull request in validate mode.
+type Params struct {
+	Mode               string
+	HeadRef            string
+	BaseRef            string
+	CommitSha          string
+	RepoDir            string
+	Bump               string
+	Scheme             string
+	CalVerFormat       string
+	BaseVersion        *semver.Version
+	Prefix             string
+	PrereleaseID       string
+	PrereleaseChannels []string
+	PromoteTo          string
+	ForcePrerelease    bool
+	InitialDevelopment bool
+	ChangeDetectors    []string
+	ChangeDetectorMode string
+	Convention         conventional.Convention
+	StrategyPlugin     string
+	GitTimeout         time.Duration
+	GitSnapshot        bool
+	Metadata           string
+	Timeout            time.Duration
+	GoModule           bool
+	GoModuleRewrite    bool
+	BranchName         string
+	Debug              bool
+	// Retracted maps the tags of pulled versions, skipped as latest and
+	// ancestor tags, to the reason.
+	Retracted map[string]string
+	// Clock returns the current time of calendar versions, time.Now if nil.
+	Clock func() time.Time
+}
+
+// ParamError is returned if a param isn't one of its allowed values.
+type ParamError struct {
+	// Param is the name of the action input, e.g. change_detector_mode.
+	Param string
+	Value string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("invalid %s value: %s: %s", e.Param, e.Value, e.Err)
+	}
+
+	return fmt.Sprintf("invalid %s value: %s", e.Param, e.Value)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the params limited to a set of values. An empty mode or
+// metadata means the default.
+func (p Params) Validate() error {
+	if p.Mode != "" && !stringInSlice(p.Mode, validModes) {
+		return &ParamError{Param: "mode", Value: p.Mode}
+	}
+
+	if !stringInSlice(p.Bump, validBumpStrategies) {
+		return &ParamError{Param: "bump", Value: p.Bump}
+	}
+
+	if !stringInSlice(p.Scheme, validSchemes) {
+		return &ParamError{Param: "scheme", Value: p.Scheme}
+	}
+
+	if _, err := calver.ParseFormat(p.CalVerFormat); err != nil {
+		return &ParamError{Param: "calver_format", Value: p.CalVerFormat, Err: err}
+	}
+
+	for _, name := range p.ChangeDetectors {
+		if _, err := detect.New(name); err != nil {
+			return &ParamError{Param: "change_detectors", Value: name}
+		}
+	}
+
+	if !stringInSlice(p.ChangeDetectorMode, validChangeDetectorModes) {
+		return &ParamError{Param: "change_detector_mode", Value: p.ChangeDetectorMode}
+	}
+
+	if p.PromoteTo != "" && !stringInSlice(p.PromoteTo, p.PrereleaseChannels) {
+		return &ParamError{Param: "promote_to", Value: p.PromoteTo}
+	}
+
+	if p.Metadata != "" && !stringInSlice(p.Metadata, validMetadata) {
+		return &ParamError{Param: "metadata", Value: p.Metadata}
+	}
+
+	return nil
+}
+
+func stringInSlice(a string, list []string) bool {
+	for _, b := range list {
+		if b == a {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Params) String() string {
+	var baseVersion string
+	if p.BaseVersion != nil {
+		baseVersion = p.BaseVersion.String()
+	}
+
+	return fmt.Sprintf(
+		"mode: %q, head ref: %q, base ref: %q, commit sha: %q, bump: %q, scheme: %q, calver format: %q, base version: %q, prefix: %q,"+
+			" prerelease id: %q, prerelease channels: %q, promote to: %q,"+
+			" force prerelease: %t, initial development: %t,"+
+			" change detectors: %q, change detector mode: %q, retracted: %q, commit types: %q, commit scope required: %t,"+
+			" commit header max length: %d, strategy plugin: %q,"+
+			" git timeout: %s, git snapshot: %t, metadata: %q, timeout: %s,"+
+			" go module: %t, go module rewrite: %t, branch name: %q,"+
+			" repo dir: %q, debug: %t\n",
+		p.Mode,
+		p.HeadRef,
+		p.BaseRef,
+		p.CommitSha,
+		p.Bump,
+		p.Scheme,
+		p.CalVerFormat,
+		baseVersion,
+		p.Prefix,
+		p.PrereleaseID,
+		p.PrereleaseChannels,
+		p.PromoteTo,
+		p.ForcePrerelease,
+		p.InitialDevelopment,
+		p.ChangeDetectors,
+		p.ChangeDetectorMode,
+		retractedTags(p.Retracted),
+		p.Convention.Types,
+		p.Convention.RequireScope,
+		p.Convention.MaxHeaderLength,
+		p.StrategyPlugin,
+		p.GitTimeout,
+		p.GitSnapshot,
+		p.Metadata,
+		p.Timeout,
+		p.GoModule,
+		p.GoModuleRewrite,
+		p.BranchName,
+		p.RepoDir,
+		p.Debug,
+	)
+}
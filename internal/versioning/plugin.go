@@ -0,0 +1,80 @@
+package versioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/snapfi/semver-action/pkg/plugin"
+
+	"github.com/apex/log"
+)
+
+// pluginBumpStrategy asks the strategy plugin for the bump of the merge of
+// source into dest. ok is false if the plugin defers to the branch rules.
+func pluginBumpStrategy(ctx context.Context, params Params, gc Repository, source, dest string) (string, string, []string, bool, error) {
+	base, err := gc.AncestorTag(ctx, params.Prefix+"[0-9]*", "", dest)
+	if err != nil {
+		return "", "", nil, false, fmt.Errorf("failed to get ancestor tag: %w", err)
+	}
+
+	head := params.CommitSha
+	if head == "" {
+		head = "HEAD"
+	}
+
+	commits, err := gc.Commits(ctx, base, head)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+
+	files, err := gc.ChangedFiles(ctx, base, head)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+
+	// Without tags, the base is the root commit, which isn't a version.
+	previousTag := base
+
+	exists, err := gc.TagExists(ctx, base)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+
+	if !exists {
+		previousTag = ""
+	}
+
+	req := plugin.Request{
+		SourceBranch: source,
+		DestBranch:   dest,
+		MainBranch:   params.BranchName,
+		PreviousTag:  previousTag,
+		ChangedFiles: files,
+	}
+
+	for _, c := range commits {
+		req.Commits = append(req.Commits, plugin.Commit{Hash: c.Hash, Subject: c.Subject})
+	}
+
+	resp, err := plugin.Run(ctx, params.StrategyPlugin, params.RepoDir, req)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+
+	log.Debugf("strategy plugin bump: %q, reason: %q", resp.Bump, resp.Reason)
+
+	if resp.Bump == "" {
+		return "", "", nil, false, nil
+	}
+
+	explain := []string{fmt.Sprintf("strategy plugin: %s", resp.Bump)}
+	if resp.Reason != "" {
+		explain = append(explain, "  "+resp.Reason)
+	}
+
+	if resp.Bump == "none" {
+		return "", "", explain, true, nil
+	}
+
+	return "build", resp.Bump, explain, true, nil
+}
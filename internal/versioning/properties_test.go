@@ -0,0 +1,189 @@
+package versioning_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/snapfi/semver-action/internal/versioning"
+
+	"github.com/blang/semver/v4"
+)
+
+// tagInput is an input of Tag for property tests.
+type tagInput struct {
+	LatestTag          string
+	SourceBranch       string
+	Bump               string
+	Prefix             string
+	PrereleaseID       string
+	ForcePrerelease    bool
+	InitialDevelopment bool
+	BaseVersion        string
+}
+
+// Generate returns a random input close to real usage, so most inputs
+// calculate a version.
+func (tagInput) Generate(r *rand.Rand, _ int) reflect.Value {
+	pick := func(values ...string) string {
+		return values[r.Intn(len(values))]
+	}
+
+	version := func() string {
+		v := fmt.Sprintf("%d.%d.%d", r.Intn(3), r.Intn(3), r.Intn(3))
+		if r.Intn(2) == 0 {
+			v += fmt.Sprintf("-%s.%d", pick("alpha", "beta", "rc", "pre"), 1+r.Intn(5))
+		}
+
+		return v
+	}
+
+	in := tagInput{
+		SourceBranch:       pick("feature/some", "bugfix/some", "major/some", "docs/some", "promote/beta", "promote/rc"),
+		Bump:               pick("auto", "auto", "auto", "major", "minor", "patch", "finalize", "graduate", "promote"),
+		Prefix:             pick("", "v", "release-"),
+		PrereleaseID:       pick("alpha", "beta", "rc", "pre"),
+		ForcePrerelease:    r.Intn(2) == 0,
+		InitialDevelopment: r.Intn(4) == 0,
+	}
+
+	if r.Intn(8) != 0 {
+		in.LatestTag = in.Prefix + version()
+	}
+
+	if r.Intn(8) == 0 {
+		in.BaseVersion = version()
+	}
+
+	return reflect.ValueOf(in)
+}
+
+// checkTag runs Tag with in and returns the invariant it breaks, if any.
+func checkTag(t *testing.T, in tagInput) error {
+	params := versioning.Params{
+		CommitSha:          "81918ffc",
+		Bump:               in.Bump,
+		Prefix:             in.Prefix,
+		PrereleaseID:       in.PrereleaseID,
+		PrereleaseChannels: []string{"alpha", "beta", "rc"},
+		ForcePrerelease:    in.ForcePrerelease,
+		InitialDevelopment: in.InitialDevelopment,
+		BranchName:         "main",
+	}
+
+	var base semver.Version
+
+	if in.BaseVersion != "" {
+		parsed, err := semver.Parse(in.BaseVersion)
+		if err != nil {
+			return nil
+		}
+
+		base, params.BaseVersion = parsed, &parsed
+	}
+
+	gc := initGitClientMock(t, in.LatestTag, in.LatestTag, "main", in.SourceBranch, "81918ffc")
+
+	result, err := versioning.Tag(context.Background(), params, gc)
+
+	if params.BaseVersion != nil && !params.BaseVersion.Equals(base) {
+		return fmt.Errorf("base version changed to %s", params.BaseVersion)
+	}
+
+	// Invalid combinations fail and docs don't release.
+	if err != nil || result.SemverTag == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(result.SemverTag, in.Prefix) {
+		return fmt.Errorf("tag %q without prefix %q", result.SemverTag, in.Prefix)
+	}
+
+	version, err := semver.Parse(strings.TrimPrefix(result.SemverTag, in.Prefix))
+	if err != nil {
+		return fmt.Errorf("invalid tag %q: %w", result.SemverTag, err)
+	}
+
+	if in.Prefix+version.String() != result.SemverTag {
+		return fmt.Errorf("tag %q doesn't round trip, got %q", result.SemverTag, in.Prefix+version.String())
+	}
+
+	if result.IsPrerelease != (len(version.Pre) > 0) {
+		return fmt.Errorf("tag %q is prerelease: %t", result.SemverTag, result.IsPrerelease)
+	}
+
+	// Without tags, versions are bumped from 0.0.0.
+	var previous semver.Version
+
+	if in.LatestTag != "" || result.PreviousTag != "" {
+		previous, err = semver.ParseTolerant(strings.TrimPrefix(result.PreviousTag, in.Prefix))
+		if err != nil {
+			return fmt.Errorf("invalid previous tag %q: %w", result.PreviousTag, err)
+		}
+	}
+
+	// The base version replaces the previous tag, except to finalize it.
+	if params.BaseVersion != nil && in.Bump != "finalize" {
+		previous = base
+	}
+
+	if !version.GT(previous) {
+		return fmt.Errorf("tag %q not greater than %s", result.SemverTag, previous)
+	}
+
+	if len(version.Pre) == 2 && len(previous.Pre) == 2 &&
+		version.FinalizeVersion() == previous.FinalizeVersion() &&
+		version.Pre[0] == previous.Pre[0] && version.Pre[1].VersionNum <= previous.Pre[1].VersionNum {
+		return fmt.Errorf("prerelease counter of %q reset from %s", result.SemverTag, previous)
+	}
+
+	return nil
+}
+
+func TestTag_Properties(t *testing.T) {
+	config := &quick.Config{MaxCount: 2000, Rand: rand.New(rand.NewSource(1))} // nolint:gosec
+
+	err := quick.Check(func(in tagInput) bool {
+		if err := checkTag(t, in); err != nil {
+			t.Logf("%+v: %s", in, err)
+			return false
+		}
+
+		return true
+	}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func FuzzTag(f *testing.F) {
+	f.Add("v1.2.3", "feature/some", "auto", "v", "pre", false, false, "")
+	f.Add("v1.2.3-alpha.4", "bugfix/some", "auto", "v", "alpha", true, false, "")
+	f.Add("0.3.0", "major/some", "auto", "", "rc", false, true, "")
+	f.Add("release-1.0.0-alpha.2", "promote/beta", "auto", "release-", "alpha", true, false, "")
+	f.Add("v1.0.0-rc.3", "misc/some", "finalize", "v", "rc", false, false, "")
+	f.Add("v0.9.1", "feature/some", "graduate", "v", "pre", false, false, "")
+	f.Add("v1.2.3", "feature/some", "minor", "v", "pre", true, false, "2.0.0-beta.1")
+
+	f.Fuzz(func(t *testing.T, latestTag, sourceBranch, bump, prefix, prereleaseID string,
+		forcePrerelease, initialDevelopment bool, baseVersion string,
+	) {
+		err := checkTag(t, tagInput{
+			LatestTag:          latestTag,
+			SourceBranch:       sourceBranch,
+			Bump:               bump,
+			Prefix:             prefix,
+			PrereleaseID:       prereleaseID,
+			ForcePrerelease:    forcePrerelease,
+			InitialDevelopment: initialDevelopment,
+			BaseVersion:        baseVersion,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
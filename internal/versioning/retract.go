@@ -0,0 +1,110 @@
+package versioning
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapfi/semver-action/pkg/git"
+)
+
+// Retract adds the retractions recorded in the repository, as
+// Semver-Retracted trailers of tag annotations or notes, to the ones of
+// params and makes gc skip every retracted tag when resolving the latest and
+// ancestor tags.
+func Retract(ctx context.Context, params *Params, gc *git.Client) error {
+	if err := gc.MakeSafe(ctx); err != nil {
+		return fmt.Errorf("failed to make safe: %w", err)
+	}
+
+	isRepo, err := gc.IsRepo(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Tag reports folders that aren't repositories.
+	if !isRepo {
+		return nil
+	}
+
+	recorded, err := gc.Retractions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read retracted versions: %w", err)
+	}
+
+	retracted := make(map[string]string, len(params.Retracted)+len(recorded))
+
+	for tag, reason := range params.Retracted {
+		retracted[tag] = reason
+	}
+
+	// Recorded reasons tell more than the configuration.
+	for tag, reason := range recorded {
+		retracted[tag] = reason
+	}
+
+	params.Retracted = retracted
+	gc.Retracted = retractedTags(retracted)
+
+	return nil
+}
+
+// retractionExplain reports the existing retracted tags, which the latest
+// and ancestor tags skip.
+func retractionExplain(ctx context.Context, params Params, gc Repository) ([]string, error) {
+	var explain []string
+
+	for _, tag := range retractedTags(params.Retracted) {
+		exists, err := gc.TagExists(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			explain = append(explain, fmt.Sprintf("skipped retracted %s: %s", tag, params.Retracted[tag]))
+		}
+	}
+
+	return explain, nil
+}
+
+// retractedTags returns the sorted tags of retracted.
+func retractedTags(retracted map[string]string) []string {
+	tags := make([]string, 0, len(retracted))
+	for tag := range retracted {
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+
+	return tags
+}
+
+// skipRetracted returns tag, or the version following it if tag is retracted,
+// as pulled versions aren't released again: the prerelease counter, or else
+// the patch, is incremented until the version isn't retracted.
+func skipRetracted(params Params, scheme versionScheme, tag string) (string, []string, error) {
+	var explain []string
+
+	for {
+		if _, ok := params.Retracted[tag]; !ok {
+			return tag, explain, nil
+		}
+
+		version, err := scheme.Parse(strings.TrimPrefix(tag, params.Prefix))
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to parse retracted tag %q: %w", tag, err)
+		}
+
+		if n := len(version.Pre); n > 0 && version.Pre[n-1].IsNum {
+			version.Pre[n-1].VersionNum++
+		} else {
+			version.Patch++
+		}
+
+		next := params.Prefix + scheme.Render(version)
+		explain = append(explain, fmt.Sprintf("%s is retracted, moved to %s", tag, next))
+		tag = next
+	}
+}
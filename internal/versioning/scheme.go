@@ -0,0 +1,95 @@
+package versioning
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapfi/semver-action/pkg/calver"
+
+	"github.com/blang/semver/v4"
+)
+
+type (
+	// versionScheme parses, increments and renders versions. Calendar based
+	// schemes are mapped onto semver.Version so prerelease handling is shared.
+	versionScheme interface {
+		Parse(s string) (semver.Version, error)
+		Increment(v *semver.Version, bump string) error
+		Render(v semver.Version) string
+	}
+
+	semverScheme struct{}
+
+	calverScheme struct {
+		format calver.Format
+		now    func() time.Time
+	}
+)
+
+// newScheme returns the version scheme configured in params.
+func newScheme(params Params) (versionScheme, error) {
+	switch params.Scheme {
+	case "", "semver":
+		return semverScheme{}, nil
+	case "calver":
+		format, err := calver.ParseFormat(params.CalVerFormat)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calver format: %w", err)
+		}
+
+		now := params.Clock
+		if now == nil {
+			now = time.Now
+		}
+
+		return calverScheme{format: format, now: now}, nil
+	default:
+		return nil, fmt.Errorf("invalid version scheme: %s", params.Scheme)
+	}
+}
+
+// Parse parses a semantic version.
+func (semverScheme) Parse(s string) (semver.Version, error) {
+	return semver.ParseTolerant(s)
+}
+
+// Increment bumps the major, minor or patch version.
+func (semverScheme) Increment(v *semver.Version, bump string) error {
+	switch bump {
+	case "major":
+		return v.IncrementMajor()
+	case "minor":
+		return v.IncrementMinor()
+	case "patch":
+		return v.IncrementPatch()
+	default:
+		return fmt.Errorf("invalid bump: %s", bump)
+	}
+}
+
+// Render renders a semantic version.
+func (semverScheme) Render(v semver.Version) string {
+	return v.String()
+}
+
+// Parse parses a calendar version.
+func (s calverScheme) Parse(str string) (semver.Version, error) {
+	return s.format.Parse(str)
+}
+
+// Increment moves to the current period. Any bump rolls MICRO within the same period.
+func (s calverScheme) Increment(v *semver.Version, _ string) error {
+	next, err := s.format.Next(*v, s.now())
+	if err != nil {
+		return err
+	}
+
+	v.Major, v.Minor, v.Patch = next.Major, next.Minor, next.Patch
+
+	return nil
+}
+
+// Render renders a calendar version.
+func (s calverScheme) Render(v semver.Version) string {
+	return s.format.Render(v)
+}
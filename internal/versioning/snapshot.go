@@ -0,0 +1,134 @@
+package versioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// snapshot returns the version of the commit from its distance to the
+// ancestor tag, like git describe: the next version the branch releases, or
+// the ancestor prerelease, followed by the number of commits since the
+// ancestor tag and the abbreviated commit hash, e.g. v1.6.0-pre.0.14.gabc1234
+// on a feature branch. No counter is read from other tags, so every run on a
+// commit returns the same version.
+func snapshot(ctx context.Context, params Params, gc Repository, dest string) (Result, error) {
+	// Calendar versions move with the current date.
+	if params.Scheme == "calver" {
+		return Result{}, errors.New("snapshot bump needs the semver scheme")
+	}
+
+	scheme, err := newScheme(params)
+	if err != nil {
+		return Result{}, err
+	}
+
+	head := params.CommitSha
+	if head == "" {
+		head = "HEAD"
+	}
+
+	desc, err := gc.Describe(ctx, params.Prefix+"[0-9]*", head)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to describe %s: %w", head, err)
+	}
+
+	tag, _ := semver.New(tagDefault)
+
+	if desc.Tag != "" {
+		parsed, err := scheme.Parse(strings.TrimPrefix(desc.Tag, params.Prefix))
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to parse tag %q or not valid semantic version: %w", desc.Tag, err)
+		}
+
+		tag = &parsed
+	}
+
+	var previousTag string
+	if desc.Tag != "" {
+		previousTag = params.Prefix + scheme.Render(*tag)
+	}
+
+	if params.BaseVersion != nil {
+		// Copied, as the tag is incremented in place.
+		base := *params.BaseVersion
+		tag = &base
+	}
+
+	if len(tag.Pre) == 0 {
+		bump, err := snapshotBump(ctx, params, gc, dest)
+		if err != nil {
+			return Result{}, err
+		}
+
+		if params.InitialDevelopment && tag.Major == 0 {
+			bump = shiftBump(bump)
+		}
+
+		if err := scheme.Increment(tag, bump); err != nil {
+			return Result{}, fmt.Errorf("failed to increment %s version: %w", bump, err)
+		}
+
+		preVersion, err := semver.NewPRVersion(params.PrereleaseID)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to create new prerelease version: %w", err)
+		}
+
+		zero, _ := semver.NewPRVersion("0")
+
+		tag.Pre = []semver.PRVersion{preVersion, zero}
+	}
+
+	distance, _ := semver.NewPRVersion(strconv.Itoa(desc.Distance))
+
+	// The g keeps hashes made of digits alphanumeric, as in git describe.
+	hash, err := semver.NewPRVersion("g" + desc.Hash)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create commit hash version: %w", err)
+	}
+
+	tag.Pre = append(tag.Pre, distance, hash)
+	tag.Build = nil
+
+	since := desc.Tag
+	if since == "" {
+		since = "the first commit"
+	}
+
+	return Result{
+		PreviousTag:  previousTag,
+		AncestorTag:  desc.Tag,
+		SemverTag:    params.Prefix + scheme.Render(*tag),
+		IsPrerelease: true,
+		Snapshot:     true,
+		Rule:         "bump input snapshot",
+		Explain:      []string{fmt.Sprintf("snapshot of %s at distance %d from %s", desc.Hash, desc.Distance, since)},
+	}, nil
+}
+
+// snapshotBump returns the bump the branch rules give the branch of the
+// commit merging into the main branch, or on the main branch, the branch the
+// commit merged. It is patch if no rule releases a version.
+func snapshotBump(ctx context.Context, params Params, gc Repository, dest string) (string, error) {
+	branch := dest
+
+	if dest == params.BranchName {
+		source, err := mergedBranch(ctx, gc, params.CommitSha)
+		if err != nil {
+			return "", err
+		}
+
+		branch = source
+	}
+
+	_, version, err := determineBumpStrategy("auto", branch, params.BranchName, params.BranchName)
+	if err != nil || version == "" {
+		return "patch", nil
+	}
+
+	return version, nil
+}
@@ -0,0 +1,63 @@
+package versioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// pullRequestClient answers the git queries of Tag as if the pull request
+// from source into dest was merged. On pull_request events the checked out
+// commit is the merge of the pull request, which dest may not exist next to.
+type pullRequestClient struct {
+	Repository
+	source, dest string
+}
+
+// CurrentBranch returns the base branch of the pull request.
+func (c pullRequestClient) CurrentBranch(context.Context) (string, error) {
+	return c.dest, nil
+}
+
+// SourceBranch returns the head branch of the pull request.
+func (c pullRequestClient) SourceBranch(context.Context, string) (string, error) {
+	return c.source, nil
+}
+
+// AncestorTag looks for the tag from the merge commit instead of dest.
+func (c pullRequestClient) AncestorTag(ctx context.Context, include, exclude, branch string) (string, error) {
+	if branch == c.dest {
+		branch = "HEAD"
+	}
+
+	return c.Repository.AncestorTag(ctx, include, exclude, branch)
+}
+
+// Validate checks the head branch of a pull request against the branch rules
+// and returns the version merging it would produce. Pull requests into other
+// branches than the main one pass, as the rules don't apply to them. Unlike
+// generate, Validate never changes the work tree.
+func Validate(ctx context.Context, params Params, gc Repository) (Result, error) {
+	if params.BaseRef != params.BranchName {
+		reason := fmt.Sprintf("pull request into %s doesn't release", params.BaseRef)
+
+		latestTag, err := gc.LatestTag(ctx)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to get latest tag: %w", err)
+		}
+
+		return Result{
+			PreviousTag: latestTag,
+			BumpType:    "none",
+			Reason:      reason,
+			Explain:     []string{reason},
+		}, nil
+	}
+
+	result, err := Calculate(ctx, params, pullRequestClient{Repository: gc, source: params.HeadRef, dest: params.BaseRef})
+	if errors.Is(err, ErrInvalidBumpStrategy) {
+		return Result{}, &BranchError{Branch: params.HeadRef, Base: params.BaseRef}
+	}
+
+	return result, err
+}
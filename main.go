@@ -1,24 +1,89 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/snapfi/semver-action/cmd/generate"
+	"github.com/snapfi/semver-action/internal/versioning"
+	"github.com/snapfi/semver-action/pkg/actions"
+	"github.com/snapfi/semver-action/pkg/git"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/cli"
 	uuid "github.com/nu7hatch/gouuid"
 )
 
+// Exit codes for wrapper scripts to branch on the kind of failure.
+const (
+	exitFailure         = 1
+	exitInvalidParams   = 2
+	exitNotRepository   = 3
+	exitUnknownRevision = 4
+	exitTimeout         = 5
+	exitVersionExists   = 6
+	exitGitFailure      = 7
+)
+
 func main() {
 	log.SetHandler(cli.Default)
 
+	if len(os.Args) > 1 && os.Args[1] == "test-scenario" {
+		os.Exit(testScenario(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		os.Exit(history(os.Args[2:]))
+	}
+
+	// The action passes the mode input as first argument, so the command
+	// line spelling selects the same mode.
+	if len(os.Args) > 1 && os.Args[1] == "lint-commits" && actions.GetInput("mode") == "" {
+		_ = os.Setenv("INPUT_MODE", "lint-commits")
+	}
+
 	result, err := generate.Run()
 	if err != nil {
 		log.Errorf("failed to generate semver version: %s\n", err)
 
-		os.Exit(1)
+		var (
+			branchErr  *versioning.BranchError
+			commitsErr *versioning.CommitsError
+		)
+
+		if errors.As(err, &branchErr) {
+			fmt.Println(actions.Annotation("error", "Invalid branch name", branchErr.Error()))
+		}
+
+		if errors.As(err, &commitsErr) {
+			for _, commit := range commitsErr.Commits {
+				message := fmt.Sprintf("%.7s %s: %s", commit.Hash, commit.Header, strings.Join(commit.Problems, "; "))
+				fmt.Println(actions.Annotation("error", "Invalid commit message", message))
+			}
+		}
+
+		os.Exit(exitCode(err))
+	}
+
+	if actions.GetInput("mode") == "lint-commits" {
+		for _, line := range result.Explain {
+			log.Info(line)
+		}
+
+		return
+	}
+
+	if actions.GetInput("mode") == "validate" {
+		message := "Merging this pull request doesn't release a version."
+		if result.ShouldRelease() {
+			message = fmt.Sprintf("Merging this pull request releases %s.", result.SemverTag)
+		}
+
+		fmt.Println(actions.Annotation("notice", "Next version", message))
 	}
 
 	outputFilepath := os.Getenv("GITHUB_OUTPUT")
@@ -29,7 +94,7 @@ func main() {
 	if err := setOutput(outputFilepath, "PREVIOUS_TAG", result.PreviousTag); err != nil {
 		log.Errorf("%s\n", err)
 
-		os.Exit(1)
+		os.Exit(exitFailure)
 	}
 
 	// Print ancestor tag.
@@ -38,7 +103,7 @@ func main() {
 	if err := setOutput(outputFilepath, "ANCESTOR_TAG", result.AncestorTag); err != nil {
 		log.Errorf("%s\n", err)
 
-		os.Exit(1)
+		os.Exit(exitFailure)
 	}
 
 	// Print calculated semver tag.
@@ -47,7 +112,7 @@ func main() {
 	if err := setOutput(outputFilepath, "SEMVER_TAG", result.SemverTag); err != nil {
 		log.Errorf("%s\n", err)
 
-		os.Exit(1)
+		os.Exit(exitFailure)
 	}
 
 	// Print is prerelease.
@@ -56,7 +121,96 @@ func main() {
 	if err := setOutput(outputFilepath, "IS_PRERELEASE", fmt.Sprintf("%v", result.IsPrerelease)); err != nil {
 		log.Errorf("%s\n", err)
 
-		os.Exit(1)
+		os.Exit(exitFailure)
+	}
+
+	// Print whether a version is released, its bump and the reason if not.
+	log.Infof("SHOULD_RELEASE: %v", result.ShouldRelease())
+
+	if err := setOutput(outputFilepath, "SHOULD_RELEASE", fmt.Sprintf("%v", result.ShouldRelease())); err != nil {
+		log.Errorf("%s\n", err)
+
+		os.Exit(exitFailure)
+	}
+
+	log.Infof("BUMP_TYPE: %s", result.BumpType)
+
+	if err := setOutput(outputFilepath, "BUMP_TYPE", result.BumpType); err != nil {
+		log.Errorf("%s\n", err)
+
+		os.Exit(exitFailure)
+	}
+
+	log.Infof("REASON: %s", result.Reason)
+
+	if err := setOutput(outputFilepath, "REASON", result.Reason); err != nil {
+		log.Errorf("%s\n", err)
+
+		os.Exit(exitFailure)
+	}
+
+	// Print skipped.
+	log.Infof("SKIPPED: %v", result.Skipped)
+
+	if err := setOutput(outputFilepath, "SKIPPED", fmt.Sprintf("%v", result.Skipped)); err != nil {
+		log.Errorf("%s\n", err)
+
+		os.Exit(exitFailure)
+	}
+
+	// Print explanation.
+	for _, line := range result.Explain {
+		log.Infof("EXPLAIN: %s", line)
+	}
+
+	if err := setOutput(outputFilepath, "EXPLAIN", strings.Join(result.Explain, "\n")); err != nil {
+		log.Errorf("%s\n", err)
+
+		os.Exit(exitFailure)
+	}
+
+	// Print version for each ecosystem.
+	keys := make([]string, 0, len(result.Formats))
+	for key := range result.Formats {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		log.Infof("%s: %s", strings.ToUpper(key), result.Formats[key])
+
+		if err := setOutput(outputFilepath, strings.ToUpper(key), result.Formats[key]); err != nil {
+			log.Errorf("%s\n", err)
+
+			os.Exit(exitFailure)
+		}
+	}
+}
+
+// exitCode maps err to the exit code of its kind.
+func exitCode(err error) int {
+	var (
+		paramsErr  *generate.ParamsError
+		timeoutErr *git.TimeoutError
+		gitErr     *git.Error
+	)
+
+	switch {
+	case errors.As(err, &paramsErr):
+		return exitInvalidParams
+	case errors.As(err, &timeoutErr), errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.Is(err, git.ErrNotRepository):
+		return exitNotRepository
+	case errors.Is(err, git.ErrUnknownRevision):
+		return exitUnknownRevision
+	case errors.Is(err, versioning.ErrVersionExists):
+		return exitVersionExists
+	case errors.As(err, &gitErr):
+		return exitGitFailure
+	default:
+		return exitFailure
 	}
 }
 
@@ -84,7 +238,7 @@ func newId() string {
 	if err != nil {
 		log.Errorf("failed to generate delimier uuid: %s\n", err)
 
-		os.Exit(1)
+		os.Exit(exitFailure)
 	}
 
 	return id.String()
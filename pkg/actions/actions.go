@@ -1,6 +1,8 @@
 package actions
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 )
@@ -13,3 +15,50 @@ func GetInput(name string) string {
 
 	return strings.TrimSpace(os.Getenv(e))
 }
+
+// GetLabels returns the pull request labels from the event payload, if any.
+func GetLabels() ([]string, error) {
+	fp := os.Getenv("GITHUB_EVENT_PATH")
+	if fp == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(fp) // nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event payload: %s", err)
+	}
+
+	var event struct {
+		PullRequest struct {
+			Labels []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+		} `json:"pull_request"`
+	}
+
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse event payload: %s", err)
+	}
+
+	var labels []string
+
+	for _, label := range event.PullRequest.Labels {
+		labels = append(labels, label.Name)
+	}
+
+	return labels, nil
+}
+
+// Annotation returns the workflow command creating an annotation of level,
+// e.g. error or notice, with title and message on the run.
+func Annotation(level, title, message string) string {
+	return fmt.Sprintf("::%s title=%s::%s", level, escapeProperty(title), escapeData(message))
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeData(s))
+}
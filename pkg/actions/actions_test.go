@@ -2,10 +2,12 @@ package actions_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/snapfi/semver-action/pkg/actions"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetInput(t *testing.T) {
@@ -37,3 +39,31 @@ func TestGetInput(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLabels(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "event.json")
+
+	err := os.WriteFile(fp, []byte(`{"pull_request":{"labels":[{"name":"bug"},{"name":"promote:rc"}]}}`), 0600)
+	require.NoError(t, err)
+
+	os.Setenv("GITHUB_EVENT_PATH", fp)
+	defer os.Unsetenv("GITHUB_EVENT_PATH")
+
+	labels, err := actions.GetLabels()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"bug", "promote:rc"}, labels)
+}
+
+func TestGetLabels_NoEvent(t *testing.T) {
+	labels, err := actions.GetLabels()
+	require.NoError(t, err)
+
+	assert.Empty(t, labels)
+}
+
+func TestAnnotation(t *testing.T) {
+	assert.Equal(t,
+		"::error title=Invalid branch%2C see docs%3A rules::100%25 wrong%0Arename it",
+		actions.Annotation("error", "Invalid branch, see docs: rules", "100% wrong\nrename it"))
+}
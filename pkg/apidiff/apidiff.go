@@ -0,0 +1,304 @@
+package apidiff
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+)
+
+// API maps every exported identifier of a module to its rendered signature.
+// Identifiers are keyed by package directory, or package name at the module
+// root, and methods and struct fields as pkg.Type.Name.
+type API map[string]string
+
+// Report lists the differences between two APIs.
+type Report struct {
+	Removed []string
+	Changed []string
+	Added   []string
+}
+
+// Load parses the Go files of a module, keyed by their slash separated path,
+// and returns its exported API. Test files, testdata, internal and main
+// packages are not part of the API. Identifiers declared in several files,
+// e.g. behind build tags, take the signature of the last file by name.
+func Load(files map[string][]byte) (API, error) {
+	api := API{}
+	fset := token.NewFileSet()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !isAPIFile(name) {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, name, files[name], parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", name, err)
+		}
+
+		if f.Name.Name == "main" {
+			continue
+		}
+
+		pkg := path.Dir(name)
+		if pkg == "." {
+			pkg = f.Name.Name
+		}
+
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				loadFunc(api, fset, pkg, d)
+			case *ast.GenDecl:
+				loadGen(api, fset, pkg, d)
+			}
+		}
+	}
+
+	return api, nil
+}
+
+// Compare returns the differences from old to cur.
+func Compare(old, cur API) Report {
+	var report Report
+
+	for name, sig := range old {
+		newSig, ok := cur[name]
+
+		switch {
+		case !ok:
+			report.Removed = append(report.Removed, name)
+		case newSig != sig:
+			report.Changed = append(report.Changed, name)
+		}
+	}
+
+	for name := range cur {
+		if _, ok := old[name]; !ok {
+			report.Added = append(report.Added, name)
+		}
+	}
+
+	sort.Strings(report.Removed)
+	sort.Strings(report.Changed)
+	sort.Strings(report.Added)
+
+	return report
+}
+
+// Bump classifies the report. Removed or changed identifiers are a major
+// change, added ones minor and anything else a patch.
+func (r Report) Bump() string {
+	switch {
+	case len(r.Removed) > 0 || len(r.Changed) > 0:
+		return "major"
+	case len(r.Added) > 0:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// Incompatible describes every change breaking the API.
+func (r Report) Incompatible() []string {
+	var changes []string
+
+	for _, name := range r.Removed {
+		changes = append(changes, "removed "+name)
+	}
+
+	for _, name := range r.Changed {
+		changes = append(changes, "changed "+name)
+	}
+
+	return changes
+}
+
+func loadFunc(api API, fset *token.FileSet, pkg string, d *ast.FuncDecl) {
+	if !d.Name.IsExported() {
+		return
+	}
+
+	name := pkg + "." + d.Name.Name
+
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		recv := receiverName(d.Recv.List[0].Type)
+		if !ast.IsExported(recv) {
+			return
+		}
+
+		name = pkg + "." + recv + "." + d.Name.Name
+	}
+
+	api[name] = "func" + signature(fset, d.Type)
+}
+
+func loadGen(api API, fset *token.FileSet, pkg string, d *ast.GenDecl) {
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			if !s.Name.IsExported() {
+				continue
+			}
+
+			name := pkg + "." + s.Name.Name
+
+			if it, ok := s.Type.(*ast.InterfaceType); ok {
+				api[name] = "type interface{" + interfaceMethods(fset, it) + "}"
+				continue
+			}
+
+			st, ok := s.Type.(*ast.StructType)
+			if !ok {
+				api[name] = "type " + render(fset, s.Type)
+				continue
+			}
+
+			// Fields are tracked one by one, so adding a field is not a change of the type.
+			api[name] = "type struct"
+
+			for _, field := range st.Fields.List {
+				for _, fieldName := range field.Names {
+					if fieldName.IsExported() {
+						api[name+"."+fieldName.Name] = "field " + render(fset, field.Type)
+					}
+				}
+
+				if len(field.Names) == 0 {
+					embedded := receiverName(field.Type)
+					if ast.IsExported(embedded) {
+						api[name+"."+embedded] = "embedded " + render(fset, field.Type)
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for _, valueName := range s.Names {
+				if !valueName.IsExported() {
+					continue
+				}
+
+				sig := d.Tok.String()
+				if s.Type != nil {
+					sig += " " + render(fset, s.Type)
+				}
+
+				api[pkg+"."+valueName.Name] = sig
+			}
+		}
+	}
+}
+
+// signature renders the parameter and result types of a function, leaving
+// out their names as renaming a parameter does not change the API.
+func signature(fset *token.FileSet, ft *ast.FuncType) string {
+	result := "(" + fieldTypes(fset, ft.Params) + ")"
+
+	if ft.TypeParams != nil {
+		result = "[" + fieldTypes(fset, ft.TypeParams) + "]" + result
+	}
+
+	if ft.Results != nil {
+		result += " (" + fieldTypes(fset, ft.Results) + ")"
+	}
+
+	return result
+}
+
+func fieldTypes(fset *token.FileSet, fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+
+	var types []string
+
+	for _, field := range fields.List {
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+
+		for i := 0; i < n; i++ {
+			types = append(types, render(fset, field.Type))
+		}
+	}
+
+	return strings.Join(types, ", ")
+}
+
+func interfaceMethods(fset *token.FileSet, it *ast.InterfaceType) string {
+	var methods []string
+
+	for _, field := range it.Methods.List {
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			methods = append(methods, render(fset, field.Type))
+			continue
+		}
+
+		for _, name := range field.Names {
+			methods = append(methods, name.Name+signature(fset, ft))
+		}
+	}
+
+	sort.Strings(methods)
+
+	return strings.Join(methods, "; ")
+}
+
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	default:
+		return ""
+	}
+}
+
+func render(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+
+	_ = printer.Fprint(&buf, fset, node)
+
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func isAPIFile(name string) bool {
+	if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+		return false
+	}
+
+	dir := path.Dir(name)
+	if dir == "." {
+		return true
+	}
+
+	for _, segment := range strings.Split(dir, "/") {
+		if segment == "internal" || segment == "testdata" || segment == "vendor" ||
+			strings.HasPrefix(segment, ".") || strings.HasPrefix(segment, "_") {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,168 @@
+package apidiff_test
+
+import (
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/apidiff"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseSrc = `package lib
+
+// Client talks to the server.
+type Client struct {
+	Addr    string
+	timeout int
+}
+
+// Doer does things.
+type Doer interface {
+	Do(name string) error
+}
+
+// Version is the library version.
+const Version = "1.0.0"
+
+// New creates a client.
+func New(addr string) *Client { return &Client{Addr: addr} }
+
+// Get gets a value.
+func (c *Client) Get(key string) (string, error) { return "", nil }
+
+func helper() {}
+`
+
+func TestCompare(t *testing.T) {
+	tests := map[string]struct {
+		Src          string
+		Bump         string
+		Incompatible []string
+	}{
+		"unchanged except internals and docs": {
+			Src: `package lib
+
+type Client struct {
+	Addr    string
+	retries int
+}
+
+type Doer interface {
+	// Do does a thing.
+	Do(n string) error
+}
+
+const Version = "1.1.0"
+
+func New(address string) *Client { return nil }
+
+func (c *Client) Get(k string) (string, error) { return "", nil }
+`,
+			Bump: "patch",
+		},
+		"added identifiers": {
+			Src: baseSrc + `
+// Timeout is exported now.
+func (c *Client) Put(key, value string) error { return nil }
+
+type Option func(*Client)
+`,
+			Bump: "minor",
+		},
+		"added struct field": {
+			Src: `package lib
+
+type Client struct {
+	Addr string
+	Port int
+}
+
+type Doer interface {
+	Do(name string) error
+}
+
+const Version = "1.0.0"
+
+func New(addr string) *Client { return nil }
+
+func (c *Client) Get(key string) (string, error) { return "", nil }
+`,
+			Bump: "minor",
+		},
+		"removed and changed identifiers": {
+			Src: `package lib
+
+type Client struct {
+	Addr int
+}
+
+type Doer interface {
+	Do(name string) error
+	Close() error
+}
+
+const Version = "1.0.0"
+
+func New(addr string, port int) *Client { return nil }
+`,
+			Bump: "major",
+			Incompatible: []string{
+				"removed pkg.Client.Get",
+				"changed pkg.Client.Addr",
+				"changed pkg.Doer",
+				"changed pkg.New",
+			},
+		},
+	}
+
+	old, err := apidiff.Load(map[string][]byte{"pkg/lib.go": []byte(baseSrc)})
+	require.NoError(t, err)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cur, err := apidiff.Load(map[string][]byte{"pkg/lib.go": []byte(test.Src)})
+			require.NoError(t, err)
+
+			report := apidiff.Compare(old, cur)
+
+			assert.Equal(t, test.Bump, report.Bump())
+			assert.Equal(t, test.Incompatible, report.Incompatible())
+		})
+	}
+}
+
+func TestLoad_SkipsNonAPIFiles(t *testing.T) {
+	api, err := apidiff.Load(map[string][]byte{
+		"lib.go":                   []byte("package lib\n\nfunc Exported() {}\n"),
+		"lib_test.go":              []byte("package lib\n\nfunc TestExported() {}\n"),
+		"internal/util/util.go":    []byte("package util\n\nfunc Util() {}\n"),
+		"cmd/tool/main.go":         []byte("package main\n\nfunc Run() {}\n"),
+		"testdata/fixture/data.go": []byte("package fixture\n\nfunc Fixture() {}\n"),
+		"README.md":                []byte("# lib\n"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, apidiff.API{"lib.Exported": "func()"}, api)
+}
+
+func TestLoad_BuildTaggedDuplicates(t *testing.T) {
+	files := map[string][]byte{
+		"conn_linux.go":   []byte("//go:build linux\n\npackage lib\n\nfunc Dial(fd int) {}\n"),
+		"conn_windows.go": []byte("//go:build windows\n\npackage lib\n\nfunc Dial(handle uintptr) {}\n"),
+		"conn_darwin.go":  []byte("//go:build darwin\n\npackage lib\n\nfunc Dial(fd int32) {}\n"),
+	}
+
+	// Map iteration order changes between runs.
+	for i := 0; i < 20; i++ {
+		api, err := apidiff.Load(files)
+		require.NoError(t, err)
+
+		assert.Equal(t, apidiff.API{"lib.Dial": "func(uintptr)"}, api)
+	}
+}
+
+func TestLoad_InvalidSource(t *testing.T) {
+	_, err := apidiff.Load(map[string][]byte{"lib.go": []byte("package lib\n\nfunc {")})
+	require.Error(t, err)
+}
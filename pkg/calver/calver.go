@@ -0,0 +1,223 @@
+package calver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+)
+
+// Format is a calendar versioning format like YYYY.MM.MICRO. Its segments are
+// mapped in order onto major, minor and patch of a semantic version.
+type Format struct {
+	segments []string
+}
+
+// nolint: gochecknoglobals
+var validSegments = []string{"YYYY", "YY", "0Y", "MM", "0M", "WW", "0W", "DD", "0D", "MICRO"}
+
+// ParseFormat parses a calendar versioning format.
+func ParseFormat(s string) (Format, error) {
+	segments := strings.Split(s, ".")
+	if len(segments) < 2 || len(segments) > 3 {
+		return Format{}, fmt.Errorf("format %q must have two or three segments", s)
+	}
+
+	for i, segment := range segments {
+		if !isValidSegment(segment) {
+			return Format{}, fmt.Errorf("invalid segment %q in format %q", segment, s)
+		}
+
+		if segment == "MICRO" && i != len(segments)-1 {
+			return Format{}, fmt.Errorf("MICRO must be the last segment in format %q", s)
+		}
+
+		if i == 0 && !strings.HasSuffix(segment, "Y") {
+			return Format{}, fmt.Errorf("format %q must start with a year segment", s)
+		}
+	}
+
+	return Format{segments: segments}, nil
+}
+
+// Next returns the version following current at the given time. MICRO is
+// incremented within the same period and reset to 0 on a new period.
+func (f Format) Next(current semver.Version, now time.Time) (semver.Version, error) {
+	next := semver.Version{}
+
+	samePeriod := true
+
+	for i, segment := range f.segments {
+		if segment == "MICRO" {
+			continue
+		}
+
+		value := f.periodValue(segment, now)
+		setPart(&next, i, value)
+
+		if part(current, i) != value {
+			samePeriod = false
+		}
+	}
+
+	if !f.hasMicro() {
+		if samePeriod {
+			return semver.Version{}, fmt.Errorf("version for period %s already exists", f.Render(current))
+		}
+
+		return next, nil
+	}
+
+	micro := uint64(0)
+	if samePeriod {
+		micro = part(current, len(f.segments)-1) + 1
+	}
+
+	setPart(&next, len(f.segments)-1, micro)
+
+	return next, nil
+}
+
+// Parse parses a calendar version, with optional prerelease and build metadata.
+func (f Format) Parse(s string) (semver.Version, error) {
+	core := s
+	suffix := ""
+
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core, suffix = s[:i], s[i:]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != len(f.segments) {
+		return semver.Version{}, fmt.Errorf("version %q does not match format %s", s, f)
+	}
+
+	numbers := make([]string, 3)
+
+	for i := range numbers {
+		numbers[i] = "0"
+	}
+
+	for i, p := range parts {
+		value, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return semver.Version{}, fmt.Errorf("version %q does not match format %s", s, f)
+		}
+
+		numbers[i] = strconv.FormatUint(value, 10)
+	}
+
+	return semver.Parse(strings.Join(numbers, ".") + suffix)
+}
+
+// Render renders v according to the format, with prerelease and build metadata.
+func (f Format) Render(v semver.Version) string {
+	parts := make([]string, 0, len(f.segments))
+
+	for i, segment := range f.segments {
+		value := part(v, i)
+
+		if strings.HasPrefix(segment, "0") {
+			parts = append(parts, fmt.Sprintf("%02d", value))
+		} else {
+			parts = append(parts, strconv.FormatUint(value, 10))
+		}
+	}
+
+	result := strings.Join(parts, ".")
+
+	if len(v.Pre) > 0 {
+		pre := make([]string, 0, len(v.Pre))
+		for _, p := range v.Pre {
+			pre = append(pre, p.String())
+		}
+
+		result += "-" + strings.Join(pre, ".")
+	}
+
+	if len(v.Build) > 0 {
+		result += "+" + strings.Join(v.Build, ".")
+	}
+
+	return result
+}
+
+// String returns the format as it was given, e.g. YYYY.MM.MICRO.
+func (f Format) String() string {
+	return strings.Join(f.segments, ".")
+}
+
+func (f Format) hasMicro() bool {
+	return f.segments[len(f.segments)-1] == "MICRO"
+}
+
+func (f Format) hasWeek() bool {
+	for _, segment := range f.segments {
+		if segment == "WW" || segment == "0W" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// periodValue returns the value of segment at now. Formats with a week
+// segment take the year of the ISO week, which can differ from the calendar
+// year in the first and last days of the year, e.g. 2025-12-29 is in week 1
+// of 2026.
+func (f Format) periodValue(segment string, now time.Time) uint64 {
+	year := now.Year()
+	if f.hasWeek() {
+		year, _ = now.ISOWeek()
+	}
+
+	switch segment {
+	case "YYYY":
+		return uint64(year)
+	case "YY", "0Y":
+		return uint64(year - 2000)
+	case "MM", "0M":
+		return uint64(now.Month())
+	case "WW", "0W":
+		_, week := now.ISOWeek()
+		return uint64(week)
+	case "DD", "0D":
+		return uint64(now.Day())
+	}
+
+	return 0
+}
+
+func part(v semver.Version, i int) uint64 {
+	switch i {
+	case 0:
+		return v.Major
+	case 1:
+		return v.Minor
+	default:
+		return v.Patch
+	}
+}
+
+func setPart(v *semver.Version, i int, value uint64) {
+	switch i {
+	case 0:
+		v.Major = value
+	case 1:
+		v.Minor = value
+	default:
+		v.Patch = value
+	}
+}
+
+func isValidSegment(segment string) bool {
+	for _, s := range validSegments {
+		if s == segment {
+			return true
+		}
+	}
+
+	return false
+}
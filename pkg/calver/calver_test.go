@@ -0,0 +1,150 @@
+package calver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snapfi/semver-action/pkg/calver"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"single segment":    "YYYY",
+		"too many segments": "YYYY.MM.DD.MICRO",
+		"unknown segment":   "YYYY.QQ.MICRO",
+		"micro not last":    "YYYY.MICRO.MM",
+		"no leading year":   "MM.YYYY.MICRO",
+	}
+
+	for name, format := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := calver.ParseFormat(format)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFormat_Next(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		Format   string
+		Current  string
+		Expected string
+	}{
+		"same period rolls micro": {
+			Format:   "YYYY.MM.MICRO",
+			Current:  "2026.10.2",
+			Expected: "2026.10.3",
+		},
+		"new period resets micro": {
+			Format:   "YYYY.MM.MICRO",
+			Current:  "2026.9.7",
+			Expected: "2026.10.0",
+		},
+		"short year and zero padded month": {
+			Format:   "YY.0M.MICRO",
+			Current:  "26.09.4",
+			Expected: "26.10.0",
+		},
+		"week without micro": {
+			Format:   "YYYY.0W",
+			Current:  "2026.41",
+			Expected: "2026.42",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			format, err := calver.ParseFormat(test.Format)
+			require.NoError(t, err)
+
+			current, err := format.Parse(test.Current)
+			require.NoError(t, err)
+
+			next, err := format.Next(current, now)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, format.Render(next))
+		})
+	}
+}
+
+func TestFormat_Next_PeriodExists(t *testing.T) {
+	format, err := calver.ParseFormat("YYYY.0W")
+	require.NoError(t, err)
+
+	current, err := format.Parse("2026.42")
+	require.NoError(t, err)
+
+	_, err = format.Next(current, time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+
+	assert.EqualError(t, err, "version for period 2026.42 already exists")
+}
+
+func TestFormat_Next_YearBoundary(t *testing.T) {
+	tests := map[string]struct {
+		Format   string
+		Now      time.Time
+		Current  string
+		Expected string
+	}{
+		"week 1 of next year": {
+			Format:   "YYYY.0W.MICRO",
+			Now:      time.Date(2025, time.December, 29, 12, 0, 0, 0, time.UTC),
+			Current:  "2025.52.3",
+			Expected: "2026.01.0",
+		},
+		"week 53 of previous year": {
+			Format:   "YY.WW",
+			Now:      time.Date(2027, time.January, 1, 12, 0, 0, 0, time.UTC),
+			Current:  "26.52",
+			Expected: "26.53",
+		},
+		"month keeps calendar year": {
+			Format:   "YYYY.MM.MICRO",
+			Now:      time.Date(2025, time.December, 29, 12, 0, 0, 0, time.UTC),
+			Current:  "2025.12.3",
+			Expected: "2025.12.4",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			format, err := calver.ParseFormat(test.Format)
+			require.NoError(t, err)
+
+			current, err := format.Parse(test.Current)
+			require.NoError(t, err)
+
+			next, err := format.Next(current, test.Now)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, format.Render(next))
+		})
+	}
+}
+
+func TestFormat_ParseRender(t *testing.T) {
+	format, err := calver.ParseFormat("YY.0M.MICRO")
+	require.NoError(t, err)
+
+	v, err := format.Parse("26.01.3-pre.2")
+	require.NoError(t, err)
+
+	assert.True(t, semver.MustParse("26.1.3-pre.2").EQ(v))
+	assert.Equal(t, "26.01.3-pre.2", format.Render(v))
+}
+
+func TestFormat_Parse_Mismatch(t *testing.T) {
+	format, err := calver.ParseFormat("YYYY.MM.MICRO")
+	require.NoError(t, err)
+
+	_, err = format.Parse("2026.10")
+
+	assert.EqualError(t, err, `version "2026.10" does not match format YYYY.MM.MICRO`)
+}
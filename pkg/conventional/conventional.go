@@ -0,0 +1,224 @@
+// Package conventional parses commit messages following the Conventional
+// Commits specification and checks them against a convention.
+package conventional
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// nolint: gochecknoglobals
+	headerRegex = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9-]*)(?:\(([^()]*)\))?(!)?: (\S.*)$`)
+	// nolint: gochecknoglobals
+	footerRegex = regexp.MustCompile(`^(BREAKING CHANGE|[a-zA-Z0-9-]+)(?:: | #)(.*)$`)
+	// nolint: gochecknoglobals
+	breakingLikeRegex = regexp.MustCompile(`(?i)^breaking[ _-]?changes?\s*:`)
+
+	// DefaultTypes are the commit types of the Angular convention.
+	// nolint: gochecknoglobals
+	DefaultTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+	// ErrInvalidHeader is returned by Parse for headers not shaped like
+	// type(scope): description.
+	ErrInvalidHeader = errors.New("header must look like type(scope): description")
+)
+
+// DefaultMaxHeaderLength is the default maximum header length.
+const DefaultMaxHeaderLength = 100
+
+type (
+	// Commit is a parsed commit message.
+	Commit struct {
+		Header      string
+		Type        string
+		Scope       string
+		Description string
+		Body        string
+		Footers     []Footer
+		// Breaking is set by a ! after the type or scope, or by a
+		// BREAKING CHANGE footer.
+		Breaking bool
+	}
+
+	// Footer is a trailer of the message, like Refs: #12.
+	Footer struct {
+		Token string
+		Value string
+	}
+
+	// Convention holds the rules commit messages must follow.
+	Convention struct {
+		// Types lists the allowed types, any type is allowed if empty.
+		Types []string
+		// RequireScope rejects headers without a scope.
+		RequireScope bool
+		// MaxHeaderLength is the maximum length of the header, 0 for no limit.
+		MaxHeaderLength int
+	}
+)
+
+// Default returns the convention used when none is configured.
+func Default() Convention {
+	return Convention{Types: DefaultTypes, MaxHeaderLength: DefaultMaxHeaderLength}
+}
+
+// Parse parses message. The footers are the last paragraph of the message if
+// its first line is a footer, continuation lines are part of the value.
+func Parse(message string) (Commit, error) {
+	header, paragraphs := split(message)
+
+	c := Commit{Header: header}
+
+	match := headerRegex.FindStringSubmatch(header)
+	if match == nil {
+		return c, ErrInvalidHeader
+	}
+
+	c.Type, c.Scope, c.Breaking, c.Description = match[1], match[2], match[3] == "!", match[4]
+
+	c.Footers, paragraphs = splitFooters(paragraphs)
+	c.Body = strings.TrimSpace(strings.Join(paragraphs, "\n\n"))
+
+	for _, footer := range c.Footers {
+		if isBreakingToken(footer.Token) {
+			c.Breaking = true
+		}
+	}
+
+	return c, nil
+}
+
+// Trailers returns the footers of message without parsing its header, so
+// messages not following the convention, like merge commits, have them too.
+func Trailers(message string) []Footer {
+	_, paragraphs := split(message)
+	footers, _ := splitFooters(paragraphs)
+
+	return footers
+}
+
+// split returns the header of message and the paragraphs after it.
+func split(message string) (string, []string) {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+
+	header, rest, _ := strings.Cut(message, "\n")
+
+	return header, strings.Split(strings.TrimSpace(rest), "\n\n")
+}
+
+// splitFooters parses the last paragraph as footers if its first line is a
+// footer and returns them with the other paragraphs.
+func splitFooters(paragraphs []string) ([]Footer, []string) {
+	last := paragraphs[len(paragraphs)-1]
+	if first, _, _ := strings.Cut(last, "\n"); !footerRegex.MatchString(first) {
+		return nil, paragraphs
+	}
+
+	return parseFooters(last), paragraphs[:len(paragraphs)-1]
+}
+
+func parseFooters(paragraph string) []Footer {
+	var footers []Footer
+
+	for _, line := range strings.Split(paragraph, "\n") {
+		if match := footerRegex.FindStringSubmatch(line); match != nil {
+			footers = append(footers, Footer{Token: match[1], Value: match[2]})
+
+			continue
+		}
+
+		last := &footers[len(footers)-1]
+		last.Value += "\n" + line
+	}
+
+	return footers
+}
+
+func isBreakingToken(token string) bool {
+	return token == "BREAKING CHANGE" || token == "BREAKING-CHANGE"
+}
+
+// Bump returns the bump of c: major for breaking changes, minor for features,
+// patch for fixes and an empty string otherwise.
+func (c Commit) Bump() string {
+	switch {
+	case c.Breaking:
+		return "major"
+	case c.Type == "feat":
+		return "minor"
+	case c.Type == "fix":
+		return "patch"
+	default:
+		return ""
+	}
+}
+
+// Bump returns the bump of message, or an empty string if it doesn't follow
+// the convention, so malformed commits never bump.
+func (cv Convention) Bump(message string) string {
+	c, err := Parse(message)
+	if err != nil || !cv.allowsType(c.Type) {
+		return ""
+	}
+
+	return c.Bump()
+}
+
+// Lint returns the rules message breaks, or nil if it follows the convention.
+func (cv Convention) Lint(message string) []string {
+	var problems []string
+
+	c, err := Parse(message)
+
+	if n := utf8.RuneCountInString(c.Header); cv.MaxHeaderLength > 0 && n > cv.MaxHeaderLength {
+		problems = append(problems, fmt.Sprintf("header is %d characters long, the maximum is %d", n, cv.MaxHeaderLength))
+	}
+
+	if err != nil {
+		return append(problems, err.Error())
+	}
+
+	if !cv.allowsType(c.Type) {
+		problems = append(problems, fmt.Sprintf("type %q must be one of %s", c.Type, strings.Join(cv.Types, ", ")))
+	}
+
+	if cv.RequireScope && c.Scope == "" {
+		problems = append(problems, "scope is required")
+	}
+
+	// Misspelled or misplaced breaking change footers don't bump major.
+	for _, line := range strings.Split(c.Body, "\n") {
+		if breakingLikeRegex.MatchString(line) {
+			problems = append(problems, fmt.Sprintf("%q must be a \"BREAKING CHANGE: <description>\" footer in the last paragraph", line))
+		}
+	}
+
+	for _, footer := range c.Footers {
+		switch {
+		case isBreakingToken(footer.Token) && strings.TrimSpace(footer.Value) == "":
+			problems = append(problems, "breaking change footer must describe the change")
+		case !isBreakingToken(footer.Token) && breakingLikeRegex.MatchString(footer.Token+":"):
+			problems = append(problems, fmt.Sprintf("footer %q must be written \"BREAKING CHANGE\"", footer.Token))
+		}
+	}
+
+	return problems
+}
+
+func (cv Convention) allowsType(t string) bool {
+	if len(cv.Types) == 0 {
+		return true
+	}
+
+	for _, allowed := range cv.Types {
+		if allowed == t {
+			return true
+		}
+	}
+
+	return false
+}
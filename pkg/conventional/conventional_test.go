@@ -0,0 +1,195 @@
+package conventional_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/conventional"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		Message string
+		Commit  conventional.Commit
+	}{
+		"header only": {
+			Message: "fix: handle empty input",
+			Commit: conventional.Commit{
+				Header:      "fix: handle empty input",
+				Type:        "fix",
+				Description: "handle empty input",
+			},
+		},
+		"scope and bang": {
+			Message: "feat(api)!: drop v1 endpoints\n",
+			Commit: conventional.Commit{
+				Header:      "feat(api)!: drop v1 endpoints",
+				Type:        "feat",
+				Scope:       "api",
+				Description: "drop v1 endpoints",
+				Breaking:    true,
+			},
+		},
+		"body and footers": {
+			Message: "feat(parser): add arrays\n\nArrays are parsed lazily.\n\nSecond paragraph.\n\n" +
+				"BREAKING CHANGE: the parser returns\n  iterators\nRefs: #12\nReviewed-by: Dev",
+			Commit: conventional.Commit{
+				Header:      "feat(parser): add arrays",
+				Type:        "feat",
+				Scope:       "parser",
+				Description: "add arrays",
+				Body:        "Arrays are parsed lazily.\n\nSecond paragraph.",
+				Footers: []conventional.Footer{
+					{Token: "BREAKING CHANGE", Value: "the parser returns\n  iterators"},
+					{Token: "Refs", Value: "#12"},
+					{Token: "Reviewed-by", Value: "Dev"},
+				},
+				Breaking: true,
+			},
+		},
+		"hyphenated breaking footer and issue reference": {
+			Message: "fix: close files\r\n\r\nBREAKING-CHANGE: files must be closed\r\nFixes #3",
+			Commit: conventional.Commit{
+				Header:      "fix: close files",
+				Type:        "fix",
+				Description: "close files",
+				Footers: []conventional.Footer{
+					{Token: "BREAKING-CHANGE", Value: "files must be closed"},
+					{Token: "Fixes", Value: "3"},
+				},
+				Breaking: true,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			commit, err := conventional.Parse(tc.Message)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Commit, commit)
+		})
+	}
+}
+
+func TestParse_InvalidHeader(t *testing.T) {
+	for _, message := range []string{
+		"Update README",
+		"feat:missing space",
+		"feat(api: unclosed scope",
+		"feat: ",
+		"Merge pull request #1 from org/feature/some",
+	} {
+		_, err := conventional.Parse(message)
+		assert.ErrorIs(t, err, conventional.ErrInvalidHeader, message)
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	message := "Merge pull request #12 from org/feature/some\n\nAdd some\n\nRelease-As: 2.0.0\nRefs: #11"
+
+	assert.Equal(t, []conventional.Footer{
+		{Token: "Release-As", Value: "2.0.0"},
+		{Token: "Refs", Value: "#11"},
+	}, conventional.Trailers(message))
+
+	assert.Empty(t, conventional.Trailers("Merge pull request #12 from org/feature/some\n\nAdd some"))
+	assert.Empty(t, conventional.Trailers("Release-As: 2.0.0"))
+}
+
+func TestConvention_Bump(t *testing.T) {
+	cv := conventional.Default()
+
+	tests := map[string]string{
+		"feat: add parser":                          "minor",
+		"fix(io): close files":                      "patch",
+		"refactor!: rename package":                 "major",
+		"docs: explain\n\nBREAKING CHANGE: renamed": "major",
+		"chore: bump deps":                          "",
+		"Add parser":                                "",
+		"wip: try something":                        "",
+	}
+
+	for message, bump := range tests {
+		assert.Equal(t, bump, cv.Bump(message), message)
+	}
+}
+
+func TestConvention_Lint(t *testing.T) {
+	tests := map[string]struct {
+		Convention conventional.Convention
+		Message    string
+		Problems   []string
+	}{
+		"valid": {
+			Convention: conventional.Default(),
+			Message:    "feat(api): add endpoint\n\nBREAKING CHANGE: v1 is gone",
+		},
+		"invalid header": {
+			Convention: conventional.Default(),
+			Message:    "Add endpoint",
+			Problems:   []string{"header must look like type(scope): description"},
+		},
+		"unknown type": {
+			Convention: conventional.Convention{Types: []string{"feat", "fix"}},
+			Message:    "chore: bump deps",
+			Problems:   []string{`type "chore" must be one of feat, fix`},
+		},
+		"any type": {
+			Convention: conventional.Convention{},
+			Message:    "wip: try something",
+		},
+		"missing scope": {
+			Convention: conventional.Convention{RequireScope: true},
+			Message:    "fix: close files",
+			Problems:   []string{"scope is required"},
+		},
+		"long header": {
+			Convention: conventional.Convention{MaxHeaderLength: 20},
+			Message:    "fix: " + strings.Repeat("a", 20),
+			Problems:   []string{"header is 25 characters long, the maximum is 20"},
+		},
+		"non-ASCII header": {
+			Convention: conventional.Convention{MaxHeaderLength: 20},
+			Message:    "fix: " + strings.Repeat("é", 15),
+		},
+		"long invalid header": {
+			Convention: conventional.Convention{MaxHeaderLength: 10},
+			Message:    "Update the README",
+			Problems: []string{
+				"header is 17 characters long, the maximum is 10",
+				"header must look like type(scope): description",
+			},
+		},
+		"misspelled breaking footer": {
+			Convention: conventional.Default(),
+			Message:    "feat: drop v1\n\nBREAKING-CHANGES: v1 is gone",
+			Problems:   []string{`footer "BREAKING-CHANGES" must be written "BREAKING CHANGE"`},
+		},
+		"breaking footer in body": {
+			Convention: conventional.Default(),
+			Message:    "feat: drop v1\n\nBreaking change: v1 is gone\n\nRefs: #12",
+			Problems: []string{
+				`"Breaking change: v1 is gone" must be a "BREAKING CHANGE: <description>" footer in the last paragraph`,
+			},
+		},
+		"empty breaking footer": {
+			Convention: conventional.Default(),
+			Message:    "feat: drop v1\n\nBREAKING CHANGE:  \nRefs: #12",
+			Problems:   []string{"breaking change footer must describe the change"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.Problems, tc.Convention.Lint(tc.Message))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package detect
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/snapfi/semver-action/pkg/conventional"
+)
+
+// MessageSource is a Source also loading the messages of the commits between
+// two revisions.
+type MessageSource interface {
+	Source
+	Messages(base, head string) ([]string, error)
+}
+
+// Conventional reads the bump from Conventional Commits messages.
+type Conventional struct {
+	Convention conventional.Convention
+}
+
+// Name returns the detector name.
+func (Conventional) Name() string {
+	return "conventional commits"
+}
+
+// Detect classifies breaking changes as major, features as minor and fixes as
+// patch. Other commits, or commits not following the convention, don't bump.
+func (c Conventional) Detect(src Source, base, head string) (Change, error) {
+	messages, ok := src.(MessageSource)
+	if !ok {
+		return Change{}, errors.New("source doesn't load commit messages")
+	}
+
+	texts, err := messages.Messages(base, head)
+	if err != nil {
+		return Change{}, err
+	}
+
+	var change Change
+
+	for _, text := range texts {
+		bump := c.Convention.Bump(text)
+		if bump == "" {
+			continue
+		}
+
+		change.Bump = Max(change.Bump, bump)
+
+		if commit, _ := conventional.Parse(text); commit.Breaking {
+			change.Details = append(change.Details, fmt.Sprintf("breaking change: %s", commit.Header))
+		}
+	}
+
+	return change, nil
+}
@@ -0,0 +1,121 @@
+package detect
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/snapfi/semver-action/pkg/conventional"
+)
+
+type (
+	// Change is the verdict of a Detector.
+	Change struct {
+		// Bump is one of major, minor or patch, empty if the detector has
+		// nothing to judge, e.g. commits of other types than the convention's.
+		Bump string
+		// Details lists the changes found, incompatible ones first.
+		Details []string
+	}
+
+	// Source loads the files matching a filter at a revision, keyed by path.
+	Source interface {
+		Files(rev string, match func(path string) bool) (map[string][]byte, error)
+	}
+
+	// SourceFunc adapts a function to a Source.
+	SourceFunc func(rev string, match func(path string) bool) (map[string][]byte, error)
+
+	// Detector classifies the change between two revisions as a bump.
+	Detector interface {
+		Name() string
+		Detect(src Source, base, head string) (Change, error)
+	}
+)
+
+// Files calls f(rev, match).
+func (f SourceFunc) Files(rev string, match func(path string) bool) (map[string][]byte, error) {
+	return f(rev, match)
+}
+
+// nolint: gochecknoglobals
+var bumpLevels = map[string]int{"": 0, "patch": 1, "minor": 2, "major": 3}
+
+// New returns the built-in detector with the given name.
+func New(name string) (Detector, error) {
+	switch name {
+	case "go":
+		return GoAPI{}, nil
+	case "openapi":
+		return OpenAPI{}, nil
+	case "proto":
+		return Proto{}, nil
+	case "conventional":
+		return Conventional{Convention: conventional.Default()}, nil
+	default:
+		return nil, fmt.Errorf("unknown change detector: %s", name)
+	}
+}
+
+// Max returns the highest of the given bumps, or an empty string if none is set.
+func Max(bumps ...string) string {
+	var result string
+
+	for _, bump := range bumps {
+		if bumpLevels[bump] > bumpLevels[result] {
+			result = bump
+		}
+	}
+
+	return result
+}
+
+// verdict classifies incompatible changes as major, compatible ones as minor
+// and anything else as patch.
+func verdict(incompatible, compatible []string) Change {
+	sort.Strings(incompatible)
+	sort.Strings(compatible)
+
+	change := Change{Bump: "patch"}
+
+	switch {
+	case len(incompatible) > 0:
+		change.Bump = "major"
+	case len(compatible) > 0:
+		change.Bump = "minor"
+	}
+
+	change.Details = append(change.Details, incompatible...)
+
+	return change
+}
+
+// compareKeys reports keys removed from or changed in old as incompatible and
+// keys added in cur as compatible. Keys are described prefixed with kind, if any.
+func compareKeys(kind string, old, cur map[string]string) (incompatible, compatible []string) {
+	for key, value := range old {
+		curValue, ok := cur[key]
+
+		switch {
+		case !ok:
+			incompatible = append(incompatible, describe("removed", kind, key))
+		case curValue != value:
+			incompatible = append(incompatible, describe("changed", kind, key))
+		}
+	}
+
+	for key := range cur {
+		if _, ok := old[key]; !ok {
+			compatible = append(compatible, describe("added", kind, key))
+		}
+	}
+
+	return incompatible, compatible
+}
+
+func describe(action, kind, key string) string {
+	if kind == "" {
+		return action + " " + key
+	}
+
+	return action + " " + kind + " " + key
+}
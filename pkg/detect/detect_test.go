@@ -0,0 +1,315 @@
+package detect_test
+
+import (
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/detect"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sourceMock serves files by revision.
+type sourceMock map[string]map[string]string
+
+func (m sourceMock) Files(rev string, match func(path string) bool) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	for name, content := range m[rev] {
+		if match(name) {
+			files[name] = []byte(content)
+		}
+	}
+
+	return files, nil
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"go", "openapi", "proto", "conventional"} {
+		_, err := detect.New(name)
+		require.NoError(t, err)
+	}
+
+	_, err := detect.New("invalid")
+	assert.EqualError(t, err, "unknown change detector: invalid")
+}
+
+func TestMax(t *testing.T) {
+	assert.Equal(t, "major", detect.Max("minor", "major", "patch"))
+	assert.Equal(t, "minor", detect.Max("", "minor"))
+	assert.Equal(t, "", detect.Max())
+}
+
+func TestGoAPI(t *testing.T) {
+	src := sourceMock{
+		"v1.0.0": {"lib.go": "package lib\n\nfunc New() {}\n"},
+		"HEAD":   {"lib.go": "package lib\n\nfunc New(addr string) {}\n", "README.md": "# lib\n"},
+	}
+
+	change, err := detect.GoAPI{}.Detect(src, "v1.0.0", "HEAD")
+	require.NoError(t, err)
+
+	assert.Equal(t, detect.Change{Bump: "major", Details: []string{"changed lib.New"}}, change)
+}
+
+const openAPIBase = `openapi: 3.0.3
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+    post:
+      requestBody:
+        required: true
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+    delete: {}
+components:
+  schemas:
+    Pet:
+      required: [name]
+      properties:
+        name:
+          type: string
+        tag:
+          type: string
+`
+
+func TestOpenAPI(t *testing.T) {
+	tests := map[string]struct {
+		Head     string
+		Expected detect.Change
+	}{
+		"unchanged": {
+			Head:     openAPIBase,
+			Expected: detect.Change{Bump: "patch"},
+		},
+		"added endpoint, optional parameter and property": {
+			Head: `{"openapi": "3.0.3", "paths": {
+				"/pets": {
+					"get": {"parameters": [{"name": "limit", "in": "query"}, {"name": "sort", "in": "query"}]},
+					"post": {"requestBody": {"required": true}}
+				},
+				"/pets/{id}": {"parameters": [{"name": "id", "in": "path", "required": true}], "delete": {}, "get": {}}
+			}, "components": {"schemas": {"Pet": {"required": ["name"], "properties": {
+				"name": {"type": "string"}, "tag": {"type": "string"}, "age": {"type": "integer"}
+			}}}}}`,
+			Expected: detect.Change{Bump: "minor"},
+		},
+		"removed endpoint, required parameter and changed property": {
+			Head: `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+    post:
+      requestBody:
+        required: true
+components:
+  schemas:
+    Pet:
+      required: [name, tag]
+      properties:
+        name:
+          type: integer
+        tag:
+          type: string
+`,
+			Expected: detect.Change{
+				Bump: "major",
+				Details: []string{
+					"changed property Pet.name",
+					"removed endpoint DELETE /pets/{id}",
+					"required parameter query:limit of GET /pets",
+					"required property Pet.tag",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			src := sourceMock{
+				"v1.0.0": {"api/openapi.yaml": openAPIBase, "config.yml": "key: value\n"},
+				"HEAD":   {"api/openapi.yaml": test.Head, "config.yml": "key: value\n"},
+			}
+
+			change, err := detect.OpenAPI{}.Detect(src, "v1.0.0", "HEAD")
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, change)
+		})
+	}
+}
+
+const protoBase = `syntax = "proto3";
+
+package pets.v1;
+
+import "google/protobuf/timestamp.proto";
+
+// Pet is a pet.
+message Pet {
+  string name = 1;
+  /* tag of the pet */
+  string tag = 2 [deprecated = true];
+  map<string, string> labels = 3;
+  oneof owner {
+    string person = 4;
+    string company = 5;
+  }
+
+  message Toy {
+    string name = 1;
+  }
+}
+
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+  KIND_DOG = 1;
+}
+
+service PetService {
+  rpc GetPet(GetPetRequest) returns (Pet) {
+    option (google.api.http) = { get: "/v1/pets/{id}" };
+  }
+}
+`
+
+func TestProto(t *testing.T) {
+	tests := map[string]struct {
+		Head     string
+		Expected detect.Change
+	}{
+		"unchanged": {
+			Head:     protoBase,
+			Expected: detect.Change{Bump: "patch"},
+		},
+		"added field and rpc": {
+			Head: protoBase[:len(protoBase)-2] + `  rpc ListPets(ListPetsRequest) returns (stream Pet);
+}
+
+message Owner {
+  string name = 1;
+}
+`,
+			Expected: detect.Change{Bump: "minor"},
+		},
+		"removed field, enum value and changed rpc": {
+			Head: `syntax = "proto3";
+
+package pets.v1;
+
+message Pet {
+  string name = 1;
+  map<string, string> labels = 3;
+  oneof owner {
+    string person = 4;
+    string company = 5;
+  }
+
+  message Toy {
+    int64 name = 1;
+  }
+}
+
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+}
+
+service PetService {
+  rpc GetPet(GetPetRequest) returns (GetPetResponse);
+}
+`,
+			Expected: detect.Change{
+				Bump: "major",
+				Details: []string{
+					"changed field pets.v1.Pet.Toy#1",
+					"changed rpc pets.v1.PetService.GetPet",
+					"removed enum value pets.v1.Kind#1",
+					"removed field pets.v1.Pet#2",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			src := sourceMock{
+				"v1.0.0": {"proto/pets.proto": protoBase},
+				"HEAD":   {"proto/pets.proto": test.Head},
+			}
+
+			change, err := detect.Proto{}.Detect(src, "v1.0.0", "HEAD")
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, change)
+		})
+	}
+}
+
+func TestProto_Invalid(t *testing.T) {
+	src := sourceMock{
+		"v1.0.0": {"pets.proto": "message Pet {\n  string name = 1;\n"},
+	}
+
+	_, err := detect.Proto{}.Detect(src, "v1.0.0", "HEAD")
+	assert.EqualError(t, err, "failed to parse pets.proto at \"v1.0.0\": missing }")
+}
+
+// messageSourceMock serves the commit messages between two revisions.
+type messageSourceMock struct {
+	sourceMock
+	messages []string
+}
+
+func (m messageSourceMock) Messages(base, head string) ([]string, error) {
+	return m.messages, nil
+}
+
+func TestConventional(t *testing.T) {
+	tests := map[string]struct {
+		Messages []string
+		Expected detect.Change
+	}{
+		"breaking change": {
+			Messages: []string{"fix: close files", "feat(api)!: drop v1", "feat: add v2"},
+			Expected: detect.Change{Bump: "major", Details: []string{"breaking change: feat(api)!: drop v1"}},
+		},
+		"feature": {
+			Messages: []string{"fix: close files", "feat: add v2", "chore: bump deps"},
+			Expected: detect.Change{Bump: "minor"},
+		},
+		"malformed and unknown types don't bump": {
+			Messages: []string{"Add v2", "wip!: drop v1", "docs: explain"},
+			Expected: detect.Change{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			detector, err := detect.New("conventional")
+			require.NoError(t, err)
+
+			change, err := detector.Detect(messageSourceMock{messages: test.Messages}, "v1.0.0", "HEAD")
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, change)
+		})
+	}
+}
+
+func TestConventional_WithoutMessages(t *testing.T) {
+	_, err := detect.Conventional{}.Detect(sourceMock{}, "v1.0.0", "HEAD")
+	assert.EqualError(t, err, "source doesn't load commit messages")
+}
@@ -0,0 +1,50 @@
+package detect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snapfi/semver-action/pkg/apidiff"
+)
+
+// GoAPI compares the exported API of the Go packages.
+type GoAPI struct{}
+
+// Name returns the detector name.
+func (GoAPI) Name() string {
+	return "go api"
+}
+
+// Detect classifies removed or changed exported identifiers as major and
+// added ones as minor.
+func (GoAPI) Detect(src Source, base, head string) (Change, error) {
+	old, err := loadGoAPI(src, base)
+	if err != nil {
+		return Change{}, err
+	}
+
+	cur, err := loadGoAPI(src, head)
+	if err != nil {
+		return Change{}, err
+	}
+
+	report := apidiff.Compare(old, cur)
+
+	return Change{Bump: report.Bump(), Details: report.Incompatible()}, nil
+}
+
+func loadGoAPI(src Source, rev string) (apidiff.API, error) {
+	files, err := src.Files(rev, func(path string) bool {
+		return strings.HasSuffix(path, ".go")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load go files at %q: %w", rev, err)
+	}
+
+	api, err := apidiff.Load(files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load go api at %q: %w", rev, err)
+	}
+
+	return api, nil
+}
@@ -0,0 +1,275 @@
+package detect
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI compares the OpenAPI 3 documents, in YAML or JSON, of a repository.
+type OpenAPI struct{}
+
+// nolint: gochecknoglobals
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIDoc is the part of an OpenAPI document relevant for compatibility.
+type openAPIDoc struct {
+	// endpoints maps "METHOD /path" to the endpoint's parameters.
+	endpoints map[string]map[string]bool
+	// schemas maps a component schema name to its properties and their type.
+	schemas map[string]map[string]string
+	// required maps a component schema name to its required properties.
+	required map[string]map[string]bool
+}
+
+// Name returns the detector name.
+func (OpenAPI) Name() string {
+	return "openapi"
+}
+
+// Detect classifies removed endpoints, parameters or schema properties and
+// new required parameters or properties as major. Added ones are minor.
+func (OpenAPI) Detect(src Source, base, head string) (Change, error) {
+	old, err := loadOpenAPI(src, base)
+	if err != nil {
+		return Change{}, err
+	}
+
+	cur, err := loadOpenAPI(src, head)
+	if err != nil {
+		return Change{}, err
+	}
+
+	var incompatible, compatible []string
+
+	for name, oldDoc := range old {
+		curDoc, ok := cur[name]
+		if !ok {
+			incompatible = append(incompatible, "removed document "+name)
+			continue
+		}
+
+		i, c := compareOpenAPI(oldDoc, curDoc)
+		incompatible = append(incompatible, i...)
+		compatible = append(compatible, c...)
+	}
+
+	for name, curDoc := range cur {
+		if _, ok := old[name]; !ok {
+			_, c := compareOpenAPI(openAPIDoc{}, curDoc)
+			compatible = append(compatible, c...)
+		}
+	}
+
+	return verdict(incompatible, compatible), nil
+}
+
+func compareOpenAPI(old, cur openAPIDoc) (incompatible, compatible []string) {
+	for endpoint, oldParams := range old.endpoints {
+		curParams, ok := cur.endpoints[endpoint]
+		if !ok {
+			incompatible = append(incompatible, "removed endpoint "+endpoint)
+			continue
+		}
+
+		i, c := compareRequired("parameter", endpoint, oldParams, curParams)
+		incompatible = append(incompatible, i...)
+		compatible = append(compatible, c...)
+	}
+
+	for endpoint := range cur.endpoints {
+		if _, ok := old.endpoints[endpoint]; ok {
+			continue
+		}
+
+		compatible = append(compatible, "added endpoint "+endpoint)
+	}
+
+	for schema, oldProps := range old.schemas {
+		curProps, ok := cur.schemas[schema]
+		if !ok {
+			incompatible = append(incompatible, "removed schema "+schema)
+			continue
+		}
+
+		i, c := compareKeys("property", prefixKeys(schema, oldProps), prefixKeys(schema, curProps))
+		incompatible = append(incompatible, i...)
+		compatible = append(compatible, c...)
+
+		for prop := range cur.required[schema] {
+			if !old.required[schema][prop] {
+				incompatible = append(incompatible, fmt.Sprintf("required property %s.%s", schema, prop))
+			}
+		}
+	}
+
+	for schema := range cur.schemas {
+		if _, ok := old.schemas[schema]; !ok {
+			compatible = append(compatible, "added schema "+schema)
+		}
+	}
+
+	return incompatible, compatible
+}
+
+// compareRequired compares parameters mapped to whether they are required.
+func compareRequired(kind, owner string, old, cur map[string]bool) (incompatible, compatible []string) {
+	for name, oldRequired := range old {
+		curRequired, ok := cur[name]
+
+		switch {
+		case !ok:
+			incompatible = append(incompatible, fmt.Sprintf("removed %s %s of %s", kind, name, owner))
+		case curRequired && !oldRequired:
+			incompatible = append(incompatible, fmt.Sprintf("required %s %s of %s", kind, name, owner))
+		}
+	}
+
+	for name, curRequired := range cur {
+		if _, ok := old[name]; ok {
+			continue
+		}
+
+		if curRequired {
+			incompatible = append(incompatible, fmt.Sprintf("added required %s %s of %s", kind, name, owner))
+		} else {
+			compatible = append(compatible, fmt.Sprintf("added %s %s of %s", kind, name, owner))
+		}
+	}
+
+	return incompatible, compatible
+}
+
+func loadOpenAPI(src Source, rev string) (map[string]openAPIDoc, error) {
+	files, err := src.Files(rev, func(p string) bool {
+		switch path.Ext(p) {
+		case ".yaml", ".yml", ".json":
+			return true
+		default:
+			return false
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi documents at %q: %w", rev, err)
+	}
+
+	docs := make(map[string]openAPIDoc)
+
+	for name, data := range files {
+		var raw map[string]interface{}
+
+		// Files which aren't OpenAPI 3 documents are skipped, including invalid ones.
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			continue
+		}
+
+		if version, ok := raw["openapi"].(string); !ok || !strings.HasPrefix(version, "3.") {
+			continue
+		}
+
+		docs[name] = parseOpenAPI(raw)
+	}
+
+	return docs, nil
+}
+
+func parseOpenAPI(raw map[string]interface{}) openAPIDoc {
+	doc := openAPIDoc{
+		endpoints: make(map[string]map[string]bool),
+		schemas:   make(map[string]map[string]string),
+		required:  make(map[string]map[string]bool),
+	}
+
+	paths, _ := raw["paths"].(map[string]interface{})
+
+	for p, item := range paths {
+		operations, _ := item.(map[string]interface{})
+		shared := parseParameters(operations["parameters"])
+
+		for _, method := range httpMethods {
+			operation, ok := operations[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			params := make(map[string]bool)
+
+			for name, required := range shared {
+				params[name] = required
+			}
+
+			for name, required := range parseParameters(operation["parameters"]) {
+				params[name] = required
+			}
+
+			if body, ok := operation["requestBody"].(map[string]interface{}); ok {
+				required, _ := body["required"].(bool)
+				params["body"] = required
+			}
+
+			doc.endpoints[strings.ToUpper(method)+" "+p] = params
+		}
+	}
+
+	components, _ := raw["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+
+	for name, schema := range schemas {
+		s, _ := schema.(map[string]interface{})
+		props, _ := s["properties"].(map[string]interface{})
+
+		doc.schemas[name] = make(map[string]string)
+		doc.required[name] = make(map[string]bool)
+
+		for prop, def := range props {
+			d, _ := def.(map[string]interface{})
+			t, _ := d["type"].(string)
+
+			if ref, ok := d["$ref"].(string); ok {
+				t = ref
+			}
+
+			doc.schemas[name][prop] = t
+		}
+
+		required, _ := s["required"].([]interface{})
+		for _, prop := range required {
+			if p, ok := prop.(string); ok {
+				doc.required[name][p] = true
+			}
+		}
+	}
+
+	return doc
+}
+
+// parseParameters maps "in:name" of each parameter to whether it is required.
+func parseParameters(raw interface{}) map[string]bool {
+	params := make(map[string]bool)
+
+	list, _ := raw.([]interface{})
+	for _, item := range list {
+		p, _ := item.(map[string]interface{})
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		required, _ := p["required"].(bool)
+
+		if name != "" {
+			params[in+":"+name] = required
+		}
+	}
+
+	return params
+}
+
+func prefixKeys(prefix string, m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+
+	for k, v := range m {
+		result[prefix+"."+k] = v
+	}
+
+	return result
+}
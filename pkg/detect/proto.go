@@ -0,0 +1,186 @@
+package detect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Proto compares the messages, enums and services of the .proto files.
+type Proto struct{}
+
+// nolint: gochecknoglobals
+var (
+	protoCommentRegex = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	protoTokenRegex   = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|[A-Za-z0-9_.]+|[{}()<>;=,\[\]]`)
+)
+
+// Name returns the detector name.
+func (Proto) Name() string {
+	return "proto"
+}
+
+// Detect classifies removed or changed messages, fields, enum values and
+// rpcs as major. Added ones are minor. Fields are matched by number.
+func (Proto) Detect(src Source, base, head string) (Change, error) {
+	old, err := loadProto(src, base)
+	if err != nil {
+		return Change{}, err
+	}
+
+	cur, err := loadProto(src, head)
+	if err != nil {
+		return Change{}, err
+	}
+
+	return verdict(compareKeys("", old, cur)), nil
+}
+
+func loadProto(src Source, rev string) (map[string]string, error) {
+	files, err := src.Files(rev, func(path string) bool {
+		return strings.HasSuffix(path, ".proto")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load proto files at %q: %w", rev, err)
+	}
+
+	defs := make(map[string]string)
+
+	for name, data := range files {
+		if err := parseProto(string(data), defs); err != nil {
+			return nil, fmt.Errorf("failed to parse %s at %q: %w", name, rev, err)
+		}
+	}
+
+	return defs, nil
+}
+
+// parseProto adds the definitions of a .proto file to defs, keyed by kind and
+// full name, e.g. "field pkg.Msg#2" or "rpc pkg.Svc.Get".
+// nolint:gocyclo
+func parseProto(src string, defs map[string]string) error {
+	tokens := protoTokenRegex.FindAllString(protoCommentRegex.ReplaceAllString(src, " "), -1)
+
+	type scope struct {
+		kind, name string
+	}
+
+	var (
+		pkg    string
+		scopes []scope
+	)
+
+	fullName := func(name string) string {
+		parts := []string{}
+		if pkg != "" {
+			parts = append(parts, pkg)
+		}
+
+		for _, s := range scopes {
+			if s.kind != "oneof" && s.kind != "block" {
+				parts = append(parts, s.name)
+			}
+		}
+
+		if name != "" {
+			parts = append(parts, name)
+		}
+
+		return strings.Join(parts, ".")
+	}
+
+	current := func() string {
+		if len(scopes) == 0 {
+			return ""
+		}
+
+		return scopes[len(scopes)-1].kind
+	}
+
+	// statement returns the tokens up to the next ";", "{" or "}".
+	statement := func(i int) []string {
+		j := i
+		for j < len(tokens) && tokens[j] != ";" && tokens[j] != "{" && tokens[j] != "}" {
+			j++
+		}
+
+		return tokens[i:j]
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		switch {
+		case tok == "}":
+			if len(scopes) == 0 {
+				return fmt.Errorf("unexpected }")
+			}
+
+			scopes = scopes[:len(scopes)-1]
+		case tok == ";":
+			continue
+		case tok == "{":
+			scopes = append(scopes, scope{kind: "block"})
+		case tok == "package" && len(scopes) == 0:
+			stmt := statement(i + 1)
+			if len(stmt) > 0 {
+				pkg = stmt[0]
+			}
+
+			i += len(stmt)
+		case (tok == "message" || tok == "enum" || tok == "service" || tok == "oneof") &&
+			i+2 < len(tokens) && tokens[i+2] == "{":
+			if tok != "oneof" {
+				defs[tok+" "+fullName(tokens[i+1])] = tok
+			}
+
+			scopes = append(scopes, scope{kind: tok, name: tokens[i+1]})
+			i += 2
+		case tok == "rpc" && current() == "service":
+			stmt := statement(i + 1)
+			if len(stmt) > 0 {
+				defs["rpc "+fullName(stmt[0])] = strings.Join(stmt[1:], " ")
+			}
+
+			i += len(stmt)
+		case tok == "option" || tok == "reserved" || tok == "extensions" || tok == "syntax" || tok == "import":
+			i += len(statement(i + 1))
+		case current() == "enum":
+			stmt := statement(i)
+			if len(stmt) >= 3 && stmt[1] == "=" {
+				defs[fmt.Sprintf("enum value %s#%s", fullName(""), stmt[2])] = stmt[0]
+			}
+
+			i += len(stmt) - 1
+		case current() == "message" || current() == "oneof":
+			stmt := statement(i)
+
+			eq := indexOf(stmt, "=")
+			if eq >= 2 && eq+1 < len(stmt) {
+				name := stmt[eq-1]
+				typ := strings.Join(stmt[:eq-1], " ")
+				defs[fmt.Sprintf("field %s#%s", fullName(""), stmt[eq+1])] = typ + " " + name
+			}
+
+			i += len(stmt) - 1
+		default:
+			i += len(statement(i)) - 1
+		}
+	}
+
+	if len(scopes) > 0 {
+		return fmt.Errorf("missing }")
+	}
+
+	return nil
+}
+
+func indexOf(tokens []string, s string) int {
+	for i, t := range tokens {
+		if t == s {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,209 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// nolint: gochecknoglobals
+var (
+	pep440Regex = regexp.MustCompile(`^(\d+\.\d+\.\d+)(?:(a|b|rc)(\d+))?(?:\.dev(\d+))?(?:\+([0-9A-Za-z.]+))?$`)
+	nugetRegex  = regexp.MustCompile(`^(\d+\.\d+\.\d+)(?:-([0-9A-Za-z-]+))?$`)
+	debianRegex = regexp.MustCompile(`^(\d+\.\d+\.\d+)(?:~([A-Za-z][0-9A-Za-z.]*?)(\d+))?$`)
+	pep440Phase = map[string]string{
+		"alpha": "a", "a": "a",
+		"beta": "b", "b": "b",
+		"rc": "rc", "c": "rc", "pre": "rc", "preview": "rc",
+	}
+	pep440Channel = map[string]string{"a": "alpha", "b": "beta", "rc": "rc"}
+)
+
+// nugetPadding is the width numeric prerelease identifiers are padded to, as
+// NuGet compares legacy prerelease labels lexically. Numbers of more digits
+// are kept as is and sort out of order.
+const nugetPadding = 4
+
+// All returns v rendered for every supported ecosystem, keyed by output name.
+func All(v semver.Version) map[string]string {
+	return map[string]string{
+		"version_pep440":         PEP440(v),
+		"version_maven":          Maven(v),
+		"version_maven_snapshot": MavenSnapshot(v),
+		"version_nuget":          NuGet(v),
+		"version_debian":         Debian(v),
+	}
+}
+
+// PEP440 renders v as a Python package version, e.g. 1.6.0rc3. Prerelease
+// identifiers map to the a, b and rc phases and unknown ones to a dev release,
+// which PEP 440 sorts before any phase and which drops the identifier, so
+// 1.6.0-nightly.2 and 1.6.0-dev.2 both render as 1.6.0.dev2. Extra
+// identifiers and build metadata are kept as a local version.
+func PEP440(v semver.Version) string {
+	result := v.FinalizeVersion()
+
+	var local []string
+
+	if len(v.Pre) > 0 {
+		number := uint64(0)
+		rest := v.Pre[1:]
+
+		if len(rest) > 0 && rest[0].IsNum {
+			number = rest[0].VersionNum
+			rest = rest[1:]
+		}
+
+		if phase, ok := pep440Phase[strings.ToLower(v.Pre[0].String())]; ok {
+			result += phase + strconv.FormatUint(number, 10)
+		} else {
+			result += ".dev" + strconv.FormatUint(number, 10)
+		}
+
+		for _, pre := range rest {
+			local = append(local, pre.String())
+		}
+	}
+
+	local = append(local, v.Build...)
+
+	if len(local) > 0 {
+		result += "+" + strings.Join(local, ".")
+	}
+
+	return result
+}
+
+// ParsePEP440 parses a version rendered by PEP440.
+func ParsePEP440(s string) (semver.Version, error) {
+	match := pep440Regex.FindStringSubmatch(s)
+	if match == nil {
+		return semver.Version{}, fmt.Errorf("invalid pep 440 version: %s", s)
+	}
+
+	version := match[1]
+
+	switch {
+	case match[2] != "":
+		version += "-" + pep440Channel[match[2]] + "." + match[3]
+	case match[4] != "":
+		version += "-dev." + match[4]
+	}
+
+	if match[5] != "" {
+		version += "+" + match[5]
+	}
+
+	return semver.Parse(version)
+}
+
+// Maven renders v as a Maven version, e.g. 1.6.0-pre.3. Build metadata is dropped.
+func Maven(v semver.Version) string {
+	v.Build = nil
+
+	return v.String()
+}
+
+// MavenSnapshot renders v as a Maven snapshot version, e.g. 1.6.0-SNAPSHOT,
+// or as the final version if v is not a prerelease.
+func MavenSnapshot(v semver.Version) string {
+	if len(v.Pre) == 0 {
+		return v.FinalizeVersion()
+	}
+
+	return v.FinalizeVersion() + "-SNAPSHOT"
+}
+
+// NuGet renders v as a NuGet version legal for legacy clients, e.g.
+// 1.6.0-pre-0003. Identifiers are joined with hyphens and numeric ones are
+// zero padded to 4 digits so lexical ordering matches semantic ordering up to
+// 9999.
+func NuGet(v semver.Version) string {
+	result := v.FinalizeVersion()
+
+	if len(v.Pre) == 0 {
+		return result
+	}
+
+	pre := make([]string, 0, len(v.Pre))
+
+	for _, p := range v.Pre {
+		if p.IsNum {
+			pre = append(pre, fmt.Sprintf("%0*d", nugetPadding, p.VersionNum))
+		} else {
+			pre = append(pre, p.VersionStr)
+		}
+	}
+
+	return result + "-" + strings.Join(pre, "-")
+}
+
+// ParseNuGet parses a version rendered by NuGet.
+func ParseNuGet(s string) (semver.Version, error) {
+	match := nugetRegex.FindStringSubmatch(s)
+	if match == nil {
+		return semver.Version{}, fmt.Errorf("invalid nuget version: %s", s)
+	}
+
+	version := match[1]
+
+	if match[2] != "" {
+		var pre []string
+
+		for _, p := range strings.Split(match[2], "-") {
+			if n, err := strconv.ParseUint(p, 10, 64); err == nil {
+				p = strconv.FormatUint(n, 10)
+			}
+
+			pre = append(pre, p)
+		}
+
+		version += "-" + strings.Join(pre, ".")
+	}
+
+	return semver.Parse(version)
+}
+
+// Debian renders v as a Debian upstream version, e.g. 1.6.0~pre3. The tilde
+// sorts prereleases before the final version.
+func Debian(v semver.Version) string {
+	result := v.FinalizeVersion()
+
+	if len(v.Pre) > 0 {
+		result += "~"
+
+		for i, p := range v.Pre {
+			if i > 0 && !(p.IsNum && !v.Pre[i-1].IsNum) {
+				result += "."
+			}
+
+			result += p.String()
+		}
+	}
+
+	if len(v.Build) > 0 {
+		result += "+" + strings.Join(v.Build, ".")
+	}
+
+	return result
+}
+
+// ParseDebian parses a version rendered by Debian with at most a prerelease
+// identifier and number.
+func ParseDebian(s string) (semver.Version, error) {
+	match := debianRegex.FindStringSubmatch(s)
+	if match == nil {
+		return semver.Version{}, fmt.Errorf("invalid debian version: %s", s)
+	}
+
+	version := match[1]
+
+	if match[2] != "" {
+		version += "-" + match[2] + "." + match[3]
+	}
+
+	return semver.Parse(version)
+}
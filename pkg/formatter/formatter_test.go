@@ -0,0 +1,256 @@
+package formatter_test
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/formatter"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orderedVersions is sorted in ascending semantic version precedence.
+func orderedVersions() []string {
+	return []string{
+		"1.5.9",
+		"1.6.0-alpha.1",
+		"1.6.0-alpha.2",
+		"1.6.0-alpha.10",
+		"1.6.0-beta.1",
+		"1.6.0-rc.1",
+		"1.6.0-rc.3",
+		"1.6.0",
+		"1.6.1",
+		"1.10.0",
+		"2.0.0-alpha.1",
+		"2.0.0",
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := map[string]struct {
+		Version  string
+		Expected map[string]string
+	}{
+		"final": {
+			Version: "1.6.0",
+			Expected: map[string]string{
+				"version_pep440":         "1.6.0",
+				"version_maven":          "1.6.0",
+				"version_maven_snapshot": "1.6.0",
+				"version_nuget":          "1.6.0",
+				"version_debian":         "1.6.0",
+			},
+		},
+		"prerelease": {
+			Version: "1.6.0-pre.3",
+			Expected: map[string]string{
+				"version_pep440":         "1.6.0rc3",
+				"version_maven":          "1.6.0-pre.3",
+				"version_maven_snapshot": "1.6.0-SNAPSHOT",
+				"version_nuget":          "1.6.0-pre-0003",
+				"version_debian":         "1.6.0~pre3",
+			},
+		},
+		"unknown prerelease id with build metadata": {
+			Version: "1.6.0-nightly.2+gabc1234",
+			Expected: map[string]string{
+				"version_pep440":         "1.6.0.dev2+gabc1234",
+				"version_maven":          "1.6.0-nightly.2",
+				"version_maven_snapshot": "1.6.0-SNAPSHOT",
+				"version_nuget":          "1.6.0-nightly-0002",
+				"version_debian":         "1.6.0~nightly2+gabc1234",
+			},
+		},
+		"counter past the nuget padding": {
+			Version: "1.6.0-alpha.10000",
+			Expected: map[string]string{
+				"version_pep440":         "1.6.0a10000",
+				"version_maven":          "1.6.0-alpha.10000",
+				"version_maven_snapshot": "1.6.0-SNAPSHOT",
+				"version_nuget":          "1.6.0-alpha-10000",
+				"version_debian":         "1.6.0~alpha10000",
+			},
+		},
+		"extra prerelease identifiers": {
+			Version: "1.6.0-pre.0.14.gabc1234",
+			Expected: map[string]string{
+				"version_pep440":         "1.6.0rc0+14.gabc1234",
+				"version_maven":          "1.6.0-pre.0.14.gabc1234",
+				"version_maven_snapshot": "1.6.0-SNAPSHOT",
+				"version_nuget":          "1.6.0-pre-0000-0014-gabc1234",
+				"version_debian":         "1.6.0~pre0.14.gabc1234",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, formatter.All(semver.MustParse(test.Version)))
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		Format func(semver.Version) string
+		Parse  func(string) (semver.Version, error)
+	}{
+		"pep440": {Format: formatter.PEP440, Parse: formatter.ParsePEP440},
+		"nuget":  {Format: formatter.NuGet, Parse: formatter.ParseNuGet},
+		"debian": {Format: formatter.Debian, Parse: formatter.ParseDebian},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, s := range orderedVersions() {
+				v := semver.MustParse(s)
+
+				parsed, err := test.Parse(test.Format(v))
+				require.NoError(t, err)
+
+				assert.True(t, v.EQ(parsed), "%s round-tripped to %s", s, parsed)
+			}
+		})
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	tests := map[string]struct {
+		Format  func(semver.Version) string
+		Compare func(a, b string) int
+	}{
+		"pep440": {Format: formatter.PEP440, Compare: comparePEP440},
+		"nuget":  {Format: formatter.NuGet, Compare: compareNuGet},
+		"debian": {Format: formatter.Debian, Compare: compareDebian},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			versions := orderedVersions()
+
+			for i := 1; i < len(versions); i++ {
+				prev := test.Format(semver.MustParse(versions[i-1]))
+				next := test.Format(semver.MustParse(versions[i]))
+
+				assert.Equal(t, -1, test.Compare(prev, next), "expected %s < %s", prev, next)
+			}
+		})
+	}
+}
+
+// comparePEP440 compares the subset of PEP 440 versions rendered by PEP440.
+func comparePEP440(a, b string) int {
+	re := regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(?:(a|b|rc)(\d+))?`)
+	ma, mb := re.FindStringSubmatch(a), re.FindStringSubmatch(b)
+
+	for i := 1; i <= 3; i++ {
+		if c := compareInt(atoi(ma[i]), atoi(mb[i])); c != 0 {
+			return c
+		}
+	}
+
+	phase := map[string]int{"a": 0, "b": 1, "rc": 2, "": 3}
+
+	if c := compareInt(phase[ma[4]], phase[mb[4]]); c != 0 {
+		return c
+	}
+
+	return compareInt(atoi(ma[5]), atoi(mb[5]))
+}
+
+// compareNuGet compares legacy NuGet versions, whose prerelease labels are
+// compared lexically and case insensitively.
+func compareNuGet(a, b string) int {
+	coreA, preA, _ := strings.Cut(a, "-")
+	coreB, preB, _ := strings.Cut(b, "-")
+
+	if c := semver.MustParse(coreA).Compare(semver.MustParse(coreB)); c != 0 {
+		return c
+	}
+
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+
+	return strings.Compare(strings.ToLower(preA), strings.ToLower(preB))
+}
+
+// compareDebian implements the dpkg upstream version comparison algorithm.
+func compareDebian(a, b string) int {
+	order := func(c byte) int {
+		switch {
+		case c >= '0' && c <= '9':
+			return 0
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+			return int(c)
+		case c == '~':
+			return -1
+		default:
+			return int(c) + 256
+		}
+	}
+
+	for a != "" || b != "" {
+		for (a != "" && (a[0] < '0' || a[0] > '9')) || (b != "" && (b[0] < '0' || b[0] > '9')) {
+			var ca, cb int
+
+			if a != "" {
+				ca = order(a[0])
+			}
+
+			if b != "" {
+				cb = order(b[0])
+			}
+
+			if ca != cb {
+				return compareInt(ca, cb)
+			}
+
+			a, b = a[1:], b[1:]
+		}
+
+		var da, db int
+
+		for a != "" && a[0] >= '0' && a[0] <= '9' {
+			da = da*10 + int(a[0]-'0')
+			a = a[1:]
+		}
+
+		for b != "" && b[0] >= '0' && b[0] <= '9' {
+			db = db*10 + int(b[0]-'0')
+			b = b[1:]
+		}
+
+		if da != db {
+			return compareInt(da, db)
+		}
+	}
+
+	return 0
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// nolint: gochecknoglobals
+var (
+	// ErrNotRepository is matched by errors of git commands run outside a repository.
+	ErrNotRepository = errors.New("not a git repository")
+	// ErrUnknownRevision is matched by errors of git commands given a missing revision.
+	ErrUnknownRevision = errors.New("unknown revision")
+	// ErrNoTags is matched by errors of git commands finding no tag to describe a commit.
+	ErrNoTags = errors.New("no tags found")
+	// ErrNoSourceBranch is returned if the source branch can't be found in a commit message.
+	ErrNoSourceBranch = errors.New("no source branch found")
+	// ErrNoMetadata is returned if a tag has no version metadata.
+	ErrNoMetadata = errors.New("no version metadata found")
+)
+
+// nolint: gochecknoglobals
+var stderrPatterns = map[error][]string{
+	ErrNotRepository: {"not a git repository", "cannot change to"},
+	ErrUnknownRevision: {
+		"unknown revision", "bad revision", "needed a single revision",
+		"not a valid object name", "invalid object name", "bad object", "malformed object name",
+	},
+	ErrNoTags: {"no names found", "no tags can describe", "cannot describe"},
+}
+
+// Error is returned if a git command fails.
+type Error struct {
+	// Args are the arguments of the git command.
+	Args []string
+	// ExitCode is the exit code of git, -1 if it didn't run.
+	ExitCode int
+	Stderr   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("git %s: exit status %d: %s", strings.Join(e.Args, " "), e.ExitCode, e.Stderr)
+}
+
+// Is reports whether the git output matches one of the package sentinel errors.
+func (e *Error) Is(target error) bool {
+	stderr := strings.ToLower(e.Stderr)
+
+	for _, pattern := range stderrPatterns[target] {
+		if strings.Contains(stderr, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TimeoutError is returned if a git command didn't finish in time.
+type TimeoutError struct {
+	// Args are the arguments of the git command that stalled.
+	Args []string
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("git %s timed out", strings.Join(e.Args, " "))
+}
+
+// isExitCode returns true if err is a git failure with the exit code, which
+// some commands use for a negative answer.
+func isExitCode(err error, code int) bool {
+	var gitErr *Error
+
+	return errors.As(err, &gitErr) && gitErr.ExitCode == code
+}
@@ -2,22 +2,40 @@ package git
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 )
 
 var mergePRRegex = regexp.MustCompile(`Merge pull request #([0-9])+ from (?P<source>.*)+`) // nolint
 
+var describeRegex = regexp.MustCompile(`^(.+)-([0-9]+)-g([0-9a-f]+)$`) // nolint
+
 // Client is an empty struct to run git.
 type Client struct {
 	repoDir string
-	GitCmd  func(env map[string]string, args ...string) (string, error)
+	// safeDir is passed as safe.directory to every git command, if set.
+	safeDir string
+	// Timeout limits the duration of each git command, if set.
+	Timeout time.Duration
+	// Retracted lists tags LatestTag, AncestorTag and Describe skip, e.g.
+	// pulled releases kept for history, on top of the ones retracted in the
+	// version metadata.
+	Retracted []string
+	GitCmd    func(ctx context.Context, env map[string]string, args ...string) (string, error)
+
+	// recorded caches the retractions read from the version metadata.
+	recorded map[string]string
 }
 
 // NewGit creates a new git instance.
@@ -28,21 +46,30 @@ func NewGit(repoDir string) *Client {
 	}
 }
 
-// gitCmdFn runs a git command with the specified env vars and returns its output or errors.
-func gitCmdFn(env map[string]string, args ...string) (string, error) {
+// gitCmdFn runs a git command with the specified env vars merged over the
+// inherited ones and returns its output or errors. Git never prompts for
+// credentials, as there is no one to answer.
+func gitCmdFn(ctx context.Context, env map[string]string, args ...string) (string, error) {
 	var extraArgs = []string{
 		"-c", "log.showSignature=false",
 	}
 
 	args = append(extraArgs, args...)
 	/* #nosec */
-	var cmd = exec.Command("git", args...)
+	var cmd = exec.CommandContext(ctx, "git", args...)
 
-	if env != nil {
-		cmd.Env = []string{}
-		for k, v := range env {
-			cmd.Env = append(cmd.Env, k+"="+v)
-		}
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	// Later entries take precedence.
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+env[k])
 	}
 
 	stdout := bytes.Buffer{}
@@ -59,8 +86,25 @@ func gitCmdFn(env map[string]string, args ...string) (string, error) {
 		WithField("stderr", stderr.String()).
 		Debug("git result")
 
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", &TimeoutError{Args: args}
+	}
+
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), ctx.Err())
+	}
+
 	if err != nil {
-		return "", errors.New(stderr.String())
+		gitErr := &Error{Args: args, ExitCode: -1, Stderr: strings.TrimSpace(stderr.String())}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			gitErr.ExitCode = exitErr.ExitCode()
+		} else if gitErr.Stderr == "" {
+			gitErr.Stderr = err.Error()
+		}
+
+		return "", gitErr
 	}
 
 	return stdout.String(), nil
@@ -70,56 +114,96 @@ func gitCmdFn(env map[string]string, args ...string) (string, error) {
 func (Client) Clean(output string, err error) (string, error) {
 	output = strings.ReplaceAll(strings.Split(output, "\n")[0], "'", "")
 
-	if err != nil {
+	if err != nil && strings.HasSuffix(err.Error(), "\n") {
 		err = errors.New(strings.TrimSuffix(err.Error(), "\n"))
 	}
 
 	return output, err
 }
 
-// Run runs a git command and returns its output or errors.
-func (c *Client) Run(args ...string) (string, error) {
-	return c.GitCmd(nil, args...)
+// Run runs a git command and returns its output or errors. The command is
+// killed after the client timeout, if any.
+func (c *Client) Run(ctx context.Context, args ...string) (string, error) {
+	return c.RunEnv(ctx, nil, args...)
 }
 
-// MakeSafe adds safe.directory global config.
-func (c *Client) MakeSafe() error {
+// RunEnv runs a git command with extra env vars, e.g. GIT_COMMITTER_DATE.
+func (c *Client) RunEnv(ctx context.Context, env map[string]string, args ...string) (string, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	if c.safeDir != "" {
+		args = append([]string{"-c", "safe.directory=" + c.safeDir}, args...)
+	}
+
+	return c.GitCmd(ctx, env, args...)
+}
+
+// MakeSafe marks the repository as safe.directory for the git commands of
+// the client, without touching the git config.
+func (c *Client) MakeSafe(ctx context.Context) error {
 	dir, err := filepath.Abs(c.repoDir)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path for: %s", c.repoDir)
 	}
 
-	_, err = c.Run("config", "--global", "--add", "safe.directory", dir)
-	if err != nil {
-		return fmt.Errorf("failed to set safe current directory")
-	}
+	c.safeDir = dir
 
 	return nil
 }
 
-// IsRepo returns true if current folder is a git repository.
-func (c *Client) IsRepo() bool {
-	out, err := c.Run("rev-parse", "--is-inside-work-tree")
-	return err == nil && strings.TrimSpace(out) == "true"
+// IsRepo returns true if the repository directory is in a git work tree.
+// Failures other than the directory being outside of a repository, e.g. a
+// timeout, are returned.
+func (c *Client) IsRepo(ctx context.Context) (bool, error) {
+	out, err := c.Run(ctx, "-C", c.repoDir, "rev-parse", "--is-inside-work-tree")
+	if errors.Is(err, ErrNotRepository) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not check repository: %w", err)
+	}
+
+	return strings.TrimSpace(out) == "true", nil
 }
 
 // CurrentBranch returns the current branch checked out.
-func (c *Client) CurrentBranch() (string, error) {
-	dest, err := c.Clean(c.Run("-C", c.repoDir, "rev-parse", "--abbrev-ref", "HEAD", "--quiet"))
+func (c *Client) CurrentBranch(ctx context.Context) (string, error) {
+	dest, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-parse", "--abbrev-ref", "HEAD", "--quiet"))
 	if err != nil {
-		return "", fmt.Errorf("could not get current branch: %s", err)
+		return "", fmt.Errorf("could not get current branch: %w", err)
 	}
 
 	return dest, nil
 }
 
 // SourceBranch tries to get branch from commit message.
-func (c *Client) SourceBranch(commitHash string) (string, error) {
-	message, err := c.Clean(c.Run("-C", c.repoDir, "log", "-1", "--pretty=%B", commitHash))
+func (c *Client) SourceBranch(ctx context.Context, commitHash string) (string, error) {
+	message, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "log", "-1", "--pretty=%B", commitHash))
 	if err != nil {
-		return "", fmt.Errorf("could not get message from commit: %s", err)
+		return "", fmt.Errorf("could not get message from commit: %w", err)
 	}
 
+	return sourceBranchFromMessage(message)
+}
+
+// CommitMessage returns the full message of the commit at rev.
+func (c *Client) CommitMessage(ctx context.Context, rev string) (string, error) {
+	message, err := c.Run(ctx, "-C", c.repoDir, "log", "-1", "--format=%B", rev)
+	if err != nil {
+		return "", fmt.Errorf("could not get message from commit: %w", err)
+	}
+
+	return strings.TrimSpace(message), nil
+}
+
+// sourceBranchFromMessage extracts the source branch from a pull request merge message.
+func sourceBranchFromMessage(message string) (string, error) {
 	match := mergePRRegex.FindStringSubmatch(message)
 
 	paramsMap := make(map[string]string)
@@ -131,38 +215,353 @@ func (c *Client) SourceBranch(commitHash string) (string, error) {
 	}
 
 	if len(paramsMap) == 0 || paramsMap["source"] == "" {
-		return "", errors.New("no source branch found")
+		return "", ErrNoSourceBranch
 	}
 
 	splitted := strings.SplitN(paramsMap["source"], "/", 2)
 
 	if len(splitted) < 2 {
-		return "", fmt.Errorf("commit message does not contain expected format: %s", paramsMap["source"])
+		return "", fmt.Errorf(
+			"%w: commit message does not contain expected format: %s", ErrNoSourceBranch, paramsMap["source"])
 	}
 
 	return splitted[1], nil
 }
 
-// LatestTag returns the latest tag if found.
-func (c *Client) LatestTag() string {
-	var result string
+// LatestTag returns the latest tag, empty if there is none. Versions the
+// metadata retracts are skipped.
+func (c *Client) LatestTag(ctx context.Context) (string, error) {
+	retracted, err := c.retracted(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"-C", c.repoDir, "rev-list"}
+
+	// Excludes apply to the next --tags, without refs/tags/.
+	for _, tag := range retracted {
+		args = append(args, "--exclude="+tag)
+	}
+
+	commitSha, err := c.Clean(c.Run(ctx, append(args, "--tags", "--max-count=1")...))
+	if err != nil {
+		return "", fmt.Errorf("could not find the latest tagged commit: %w", err)
+	}
+
+	if commitSha == "" {
+		return "", nil
+	}
+
+	describe := append([]string{"-C", c.repoDir, "describe", "--tags"}, retractedExcludes(retracted)...)
+
+	result, err := c.Clean(c.Run(ctx, append(describe, commitSha)...))
+	if errors.Is(err, ErrNoTags) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("could not describe %s: %w", commitSha, err)
+	}
+
+	return result, nil
+}
+
+// AncestorTag returns the previous tag that matches specific pattern, or the
+// root commit if no tag matches. Like LatestTag, it skips versions the
+// metadata retracts.
+func (c *Client) AncestorTag(ctx context.Context, include, exclude, branch string) (string, error) {
+	retracted, err := c.retracted(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"-C", c.repoDir, "describe", "--tags", "--abbrev=0", "--match", include, "--exclude", exclude}
+
+	result, err := c.Clean(c.Run(ctx, append(append(args, retractedExcludes(retracted)...), branch)...))
+	if err == nil {
+		return result, nil
+	}
+
+	if !errors.Is(err, ErrNoTags) {
+		return "", fmt.Errorf("could not describe %s: %w", branch, err)
+	}
+
+	root, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-list", "--max-parents=0", "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("could not find the root commit: %w", err)
+	}
+
+	return root, nil
+}
+
+// Description is the nearest tag of a commit with the number of commits
+// since it, like git describe --long.
+type Description struct {
+	// Tag is empty if no tag matches, Distance then counts every commit.
+	Tag      string
+	Distance int
+	// Hash is the abbreviated hash of the commit.
+	Hash string
+}
+
+// Describe returns the nearest tag matching include reachable from rev, the
+// number of commits since it and the abbreviated hash of rev.
+func (c *Client) Describe(ctx context.Context, include, rev string) (Description, error) {
+	retracted, err := c.retracted(ctx)
+	if err != nil {
+		return Description{}, err
+	}
+
+	args := []string{"-C", c.repoDir, "describe", "--tags", "--long", "--abbrev=7", "--match", include}
+
+	out, err := c.Clean(c.Run(ctx, append(append(args, retractedExcludes(retracted)...), rev)...))
+	if err != nil && !errors.Is(err, ErrNoTags) {
+		return Description{}, fmt.Errorf("could not describe %s: %w", rev, err)
+	}
+
+	if err == nil {
+		match := describeRegex.FindStringSubmatch(out)
+		if match == nil {
+			return Description{}, fmt.Errorf("could not parse description %q of %s", out, rev)
+		}
+
+		distance, _ := strconv.Atoi(match[2])
+
+		return Description{Tag: match[1], Distance: distance, Hash: match[3]}, nil
+	}
+
+	// No tag matches, so every commit counts.
+	count, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-list", "--count", rev))
+	if err != nil {
+		return Description{}, fmt.Errorf("could not count commits of %s: %w", rev, err)
+	}
+
+	hash, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-parse", "--short=7", rev))
+	if err != nil {
+		return Description{}, fmt.Errorf("could not abbreviate %s: %w", rev, err)
+	}
+
+	distance, _ := strconv.Atoi(count)
+
+	return Description{Distance: distance, Hash: hash}, nil
+}
+
+// retracted returns the tags of Retracted followed by the other tags the
+// version metadata retracts, sorted.
+func (c *Client) retracted(ctx context.Context) ([]string, error) {
+	recorded, err := c.Retractions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := append([]string(nil), c.Retracted...)
+
+	var more []string
+
+	for tag := range recorded {
+		if !stringInSlice(tag, c.Retracted) {
+			more = append(more, tag)
+		}
+	}
+
+	sort.Strings(more)
+
+	return append(tags, more...), nil
+}
+
+// retractedExcludes returns the git describe arguments skipping tags.
+func retractedExcludes(tags []string) []string {
+	args := make([]string, 0, 2*len(tags))
+
+	for _, tag := range tags {
+		args = append(args, "--exclude", tag)
+	}
+
+	return args
+}
+
+// isRetracted returns true if tag is one of the retracted tags. The
+// retractions of the metadata must be read before.
+func (c *Client) isRetracted(tag string) bool {
+	_, recorded := c.recorded[tag]
+
+	return recorded || stringInSlice(tag, c.Retracted)
+}
+
+func stringInSlice(a string, list []string) bool {
+	for _, b := range list {
+		if b == a {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TagExists returns true if the given tag exists.
+func (c *Client) TagExists(ctx context.Context, tag string) (bool, error) {
+	_, err := c.Run(ctx, "-C", c.repoDir, "rev-parse", "--quiet", "--verify", "refs/tags/"+tag)
+	if isExitCode(err, 1) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not check tag %s: %w", tag, err)
+	}
+
+	return true, nil
+}
+
+// IsAncestor returns true if ancestor is reachable from commit.
+func (c *Client) IsAncestor(ctx context.Context, ancestor, commit string) (bool, error) {
+	_, err := c.Run(ctx, "-C", c.repoDir, "merge-base", "--is-ancestor", ancestor, commit)
+	if isExitCode(err, 1) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not check if %s is an ancestor of %s: %w", ancestor, commit, err)
+	}
+
+	return true, nil
+}
+
+// Files returns the content of every file matching match at the given
+// revision, keyed by path.
+func (c *Client) Files(ctx context.Context, rev string, match func(path string) bool) (map[string][]byte, error) {
+	out, err := c.Run(ctx, "-C", c.repoDir, "ls-tree", "-r", "-z", "--name-only", rev)
+	if err != nil {
+		return nil, fmt.Errorf("could not list files at %s: %w", rev, err)
+	}
+
+	files := make(map[string][]byte)
+
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" || !match(name) {
+			continue
+		}
+
+		content, err := c.Run(ctx, "-C", c.repoDir, "show", rev+":"+name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s at %s: %w", name, rev, err)
+		}
 
-	commitSha, _ := c.Clean(c.Run("-C", c.repoDir, "rev-list", "--tags", "--max-count=1"))
-	if commitSha != "" {
-		result, _ = c.Clean(c.Run("-C", c.repoDir, "describe", "--tags", commitSha))
+		files[name] = []byte(content)
 	}
 
-	return result
+	return files, nil
 }
 
-// AncestorTag returns the previous tag that matches specific pattern if found.
-func (c *Client) AncestorTag(include, exclude, branch string) string {
-	result, _ := c.Clean(c.Run(
-		"-C", c.repoDir, "describe", "--tags", "--abbrev=0",
-		"--match", include, "--exclude", exclude, branch))
-	if result == "" {
-		result, _ = c.Clean(c.Run("-C", c.repoDir, "rev-list", "--max-parents=0", "HEAD"))
+// Commit is a commit hash with its subject.
+type Commit struct {
+	Hash    string
+	Subject string
+}
+
+// Commits returns the commits reachable from to but not from from, newest first.
+func (c *Client) Commits(ctx context.Context, from, to string) ([]Commit, error) {
+	out, err := c.Run(ctx, "-C", c.repoDir, "log", "-z", "--format=%H %s", from+".."+to)
+	if err != nil {
+		return nil, fmt.Errorf("could not list commits between %s and %s: %w", from, to, err)
+	}
+
+	var commits []Commit
+
+	for _, line := range strings.Split(out, "\x00") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		hash, subject, _ := strings.Cut(line, " ")
+		commits = append(commits, Commit{Hash: hash, Subject: subject})
+	}
+
+	return commits, nil
+}
+
+// Tag is a tag with the commit it points to.
+type Tag struct {
+	Name   string
+	Commit string
+	// Date is the tagger date of annotated tags and the commit date of
+	// lightweight ones.
+	Date time.Time
+}
+
+// MergedTags returns the tags matching pattern reachable from rev, sorted by
+// name.
+func (c *Client) MergedTags(ctx context.Context, rev, pattern string) ([]Tag, error) {
+	out, err := c.Run(ctx, "-C", c.repoDir, "for-each-ref", "--merged="+rev,
+		"--format=%(refname:short)%00%(objectname)%00%(*objectname)%00%(creatordate:unix)", "refs/tags/"+pattern)
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags merged into %s: %w", rev, err)
+	}
+
+	var tags []Tag
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+
+		// Annotated tags point to the tag object, peeled to the commit.
+		commit := fields[1]
+		if fields[2] != "" {
+			commit = fields[2]
+		}
+
+		date, _ := strconv.ParseInt(fields[3], 10, 64)
+
+		tags = append(tags, Tag{Name: fields[0], Commit: commit, Date: time.Unix(date, 0).UTC()})
+	}
+
+	return tags, nil
+}
+
+// Message is a commit hash with its full message.
+type Message struct {
+	Hash string
+	Text string
+}
+
+// Messages returns the messages of the commits reachable from to but not from
+// from, newest first. Merge commits are left out, as their messages are
+// written by git or the forge rather than the author.
+func (c *Client) Messages(ctx context.Context, from, to string) ([]Message, error) {
+	out, err := c.Run(ctx, "-C", c.repoDir, "log", "-z", "--no-merges", "--format=%H %B", from+".."+to)
+	if err != nil {
+		return nil, fmt.Errorf("could not list commit messages between %s and %s: %w", from, to, err)
+	}
+
+	var messages []Message
+
+	for _, entry := range strings.Split(out, "\x00") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		hash, text, _ := strings.Cut(entry, " ")
+		messages = append(messages, Message{Hash: hash, Text: strings.TrimSpace(text)})
+	}
+
+	return messages, nil
+}
+
+// ChangedFiles returns the paths of the files changed between from and to.
+func (c *Client) ChangedFiles(ctx context.Context, from, to string) ([]string, error) {
+	out, err := c.Run(ctx, "-C", c.repoDir, "diff", "-z", "--name-only", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not list changed files between %s and %s: %w", from, to, err)
+	}
+
+	var files []string
+
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
 	}
 
-	return result
+	return files, nil
 }
@@ -1,8 +1,11 @@
 package git_test
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/snapfi/semver-action/pkg/git"
 
@@ -31,14 +34,14 @@ func TestCleanErr(t *testing.T) {
 
 func TestCurrentBranch(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-parse", "--abbrev-ref", "HEAD", "--quiet"})
 
 		return "develop", nil
 	}
 
-	value, err := gc.CurrentBranch()
+	value, err := gc.CurrentBranch(context.Background())
 	require.NoError(t, err)
 
 	assert.Equal(t, "develop", value)
@@ -46,14 +49,14 @@ func TestCurrentBranch(t *testing.T) {
 
 func TestCurrentBranchErr(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-parse", "--abbrev-ref", "HEAD", "--quiet"})
 
 		return "", errors.New("error")
 	}
 
-	_, err := gc.CurrentBranch()
+	_, err := gc.CurrentBranch(context.Background())
 	require.Error(t, err)
 
 	assert.EqualError(t, err, "could not get current branch: error")
@@ -61,54 +64,119 @@ func TestCurrentBranchErr(t *testing.T) {
 
 func TestSourceBranch(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-1", "--pretty=%B", "81918ffc"})
 
 		return "Merge pull request #123 from wakatime/feature/semver-initial", nil
 	}
 
-	value, err := gc.SourceBranch("81918ffc")
+	value, err := gc.SourceBranch(context.Background(), "81918ffc")
 	require.NoError(t, err)
 
 	assert.Equal(t, "feature/semver-initial", value)
 }
 
+func TestCommitMessage(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-1", "--format=%B", "81918ffc"})
+
+		return "Merge pull request #123 from org/feature/some\n\nAdd some\n\nRelease-As: 2.0.0\n\n", nil
+	}
+
+	message, err := gc.CommitMessage(context.Background(), "81918ffc")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Merge pull request #123 from org/feature/some\n\nAdd some\n\nRelease-As: 2.0.0", message)
+}
+
+func TestDescribe(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = withoutMetadata(func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, args, []string{
+			"-C", "/path/to/repo", "describe", "--tags", "--long", "--abbrev=7", "--match", "v[0-9]*", "81918ffc"})
+
+		return "v1.5.3-pre.1-14-gabc1234\n", nil
+	})
+
+	desc, err := gc.Describe(context.Background(), "v[0-9]*", "81918ffc")
+	require.NoError(t, err)
+
+	assert.Equal(t, git.Description{Tag: "v1.5.3-pre.1", Distance: 14, Hash: "abc1234"}, desc)
+}
+
+func TestDescribe_NoTag(t *testing.T) {
+	var numCalls int
+
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = withoutMetadata(func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		numCalls++
+
+		switch numCalls {
+		case 1:
+			assert.Equal(t, args, []string{
+				"-C", "/path/to/repo", "describe", "--tags", "--long", "--abbrev=7", "--match", "v[0-9]*", "HEAD"})
+
+			return "", &git.Error{Args: args, ExitCode: 128, Stderr: "fatal: No names found, cannot describe anything."}
+		case 2:
+			assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-list", "--count", "HEAD"})
+
+			return "3\n", nil
+		case 3:
+			assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-parse", "--short=7", "HEAD"})
+
+			return "0123abc\n", nil
+		}
+
+		return "", errors.New("unexpected call")
+	})
+
+	desc, err := gc.Describe(context.Background(), "v[0-9]*", "HEAD")
+	require.NoError(t, err)
+
+	assert.Equal(t, git.Description{Distance: 3, Hash: "0123abc"}, desc)
+	assert.Equal(t, 3, numCalls)
+}
+
 func TestSourceBranch_NotValidPullRequestMessage(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-1", "--pretty=%B", "81918ffc"})
 
 		return "not valid pull request message", nil
 	}
 
-	_, err := gc.SourceBranch("81918ffc")
+	_, err := gc.SourceBranch(context.Background(), "81918ffc")
 	require.Error(t, err)
 
-	assert.EqualError(t, err, "no source branch found")
+	assert.ErrorIs(t, err, git.ErrNoSourceBranch)
 }
 
 func TestSourceBranch_NotValiddBranchName(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-1", "--pretty=%B", "81918ffc"})
 
 		return "Merge pull request #123 from semver-initial", nil
 	}
 
-	_, err := gc.SourceBranch("81918ffc")
+	_, err := gc.SourceBranch(context.Background(), "81918ffc")
 	require.Error(t, err)
 
-	assert.EqualError(t, err, "commit message does not contain expected format: semver-initial")
+	assert.ErrorIs(t, err, git.ErrNoSourceBranch)
+	assert.EqualError(t, err, "no source branch found: commit message does not contain expected format: semver-initial")
 }
 
 func TestLatestTag(t *testing.T) {
 	var numCalls int
 
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = withoutMetadata(func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		numCalls++
 
 		assert.Nil(t, env)
@@ -124,23 +192,25 @@ func TestLatestTag(t *testing.T) {
 		}
 
 		return "v2.4.79", nil
-	}
+	})
 
-	value := gc.LatestTag()
+	value, err := gc.LatestTag(context.Background())
+	require.NoError(t, err)
 
 	assert.Equal(t, "v2.4.79", value)
 }
 
 func TestLatestTag_NoTagFound(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = withoutMetadata(func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-list", "--tags", "--max-count=1"})
 
 		return "", nil
-	}
+	})
 
-	value := gc.LatestTag()
+	value, err := gc.LatestTag(context.Background())
+	require.NoError(t, err)
 
 	assert.Empty(t, value)
 }
@@ -169,7 +239,7 @@ func TestAncestorTag(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+			gc.GitCmd = withoutMetadata(func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 				assert.Nil(t, env)
 				assert.Equal(
 					t,
@@ -180,9 +250,10 @@ func TestAncestorTag(t *testing.T) {
 				)
 
 				return test.ExpectedTag, nil
-			}
+			})
 
-			value := gc.AncestorTag(test.IncludePattern, test.ExcludePattern, test.Branch)
+			value, err := gc.AncestorTag(context.Background(), test.IncludePattern, test.ExcludePattern, test.Branch)
+			require.NoError(t, err)
 
 			assert.Equal(t, test.ExpectedTag, value)
 		})
@@ -193,7 +264,7 @@ func TestAncestorTag_NoTagFound(t *testing.T) {
 	var numCalls int
 
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = withoutMetadata(func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		numCalls++
 
 		assert.Nil(t, env)
@@ -206,14 +277,425 @@ func TestAncestorTag_NoTagFound(t *testing.T) {
 				[]string{
 					"-C", "/path/to/repo", "describe", "--tags", "--abbrev=0",
 					"--match", args[6], "--exclude", args[8], args[9]})
+
+			return "", &git.Error{Args: args, ExitCode: 128, Stderr: "fatal: No names found, cannot describe anything."}
 		case 2:
 			assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-list", "--max-parents=0", "HEAD"})
 		}
 
+		return "da81ce0ec20cab645ffe03e760dad1cdfccf7c94", nil
+	})
+
+	value, err := gc.AncestorTag(context.Background(), "", "", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "da81ce0ec20cab645ffe03e760dad1cdfccf7c94", value)
+}
+
+func TestLatestTag_Retracted(t *testing.T) {
+	var numCalls int
+
+	gc := git.NewGit("/path/to/repo")
+	gc.Retracted = []string{"v2.5.0", "v2.5.1"}
+	gc.GitCmd = withoutMetadata(func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		numCalls++
+
+		switch numCalls {
+		case 1:
+			assert.Equal(t, args, []string{
+				"-C", "/path/to/repo", "rev-list", "--exclude=v2.5.0", "--exclude=v2.5.1",
+				"--tags", "--max-count=1"})
+
+			return "da81ce0ec20cab645ffe03e760dad1cdfccf7c94", nil
+		case 2:
+			assert.Equal(t, args, []string{
+				"-C", "/path/to/repo", "describe", "--tags", "--exclude", "v2.5.0", "--exclude", "v2.5.1",
+				"da81ce0ec20cab645ffe03e760dad1cdfccf7c94"})
+		}
+
+		return "v2.4.79", nil
+	})
+
+	value, err := gc.LatestTag(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "v2.4.79", value)
+}
+
+func TestAncestorTag_Retracted(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.Retracted = []string{"v1.3.0"}
+	gc.GitCmd = withoutMetadata(func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Equal(t, args, []string{
+			"-C", "/path/to/repo", "describe", "--tags", "--abbrev=0", "--match", "v[0-9]*", "--exclude", "v[0-9]*-*",
+			"--exclude", "v1.3.0", "main"})
+
+		return "v1.2.0", nil
+	})
+
+	value, err := gc.AncestorTag(context.Background(), "v[0-9]*", "v[0-9]*-*", "main")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.2.0", value)
+}
+
+func TestTagExists(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-parse", "--quiet", "--verify", "refs/tags/v1.6.0"})
+
+		return "da81ce0ec20cab645ffe03e760dad1cdfccf7c94", nil
+	}
+
+	exists, err := gc.TagExists(context.Background(), "v1.6.0")
+	require.NoError(t, err)
+
+	assert.True(t, exists)
+}
+
+func TestTagExists_NotFound(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		return "", &git.Error{Args: args, ExitCode: 1}
+	}
+
+	exists, err := gc.TagExists(context.Background(), "v1.6.0")
+	require.NoError(t, err)
+
+	assert.False(t, exists)
+}
+
+func TestIsAncestor(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, args, []string{"-C", "/path/to/repo", "merge-base", "--is-ancestor", "v1.6.0-pre.7", "HEAD"})
+
 		return "", nil
 	}
 
-	value := gc.AncestorTag("", "", "")
+	isAncestor, err := gc.IsAncestor(context.Background(), "v1.6.0-pre.7", "HEAD")
+	require.NoError(t, err)
 
-	assert.Empty(t, value)
+	assert.True(t, isAncestor)
+}
+
+func TestIsAncestor_NotAncestor(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		return "", &git.Error{Args: args, ExitCode: 1}
+	}
+
+	isAncestor, err := gc.IsAncestor(context.Background(), "v1.6.0-pre.7", "HEAD")
+	require.NoError(t, err)
+
+	assert.False(t, isAncestor)
+}
+
+func TestQueries_Timeout(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		return "", &git.TimeoutError{Args: args}
+	}
+
+	ctx := context.Background()
+
+	queries := map[string]func() error{
+		"IsRepo": func() error {
+			_, err := gc.IsRepo(ctx)
+			return err
+		},
+		"LatestTag": func() error {
+			_, err := gc.LatestTag(ctx)
+			return err
+		},
+		"AncestorTag": func() error {
+			_, err := gc.AncestorTag(ctx, "v[0-9]*", "", "main")
+			return err
+		},
+		"Describe": func() error {
+			_, err := gc.Describe(ctx, "v[0-9]*", "HEAD")
+			return err
+		},
+		"TagExists": func() error {
+			_, err := gc.TagExists(ctx, "v1.6.0")
+			return err
+		},
+		"IsAncestor": func() error {
+			_, err := gc.IsAncestor(ctx, "v1.6.0-pre.7", "HEAD")
+			return err
+		},
+	}
+
+	for name, query := range queries {
+		query := query
+
+		t.Run(name, func(t *testing.T) {
+			var timeoutErr *git.TimeoutError
+
+			assert.True(t, errors.As(query(), &timeoutErr))
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	var numCalls int
+
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		numCalls++
+
+		assert.Nil(t, env)
+
+		switch numCalls {
+		case 1:
+			assert.Equal(t, args, []string{"-C", "/path/to/repo", "ls-tree", "-r", "-z", "--name-only", "v1.2.0"})
+			return "README.md\x00pkg/lib.go\x00", nil
+		case 2:
+			assert.Equal(t, args, []string{"-C", "/path/to/repo", "show", "v1.2.0:pkg/lib.go"})
+			return "package pkg\n", nil
+		}
+
+		return "", errors.New("unexpected call")
+	}
+
+	files, err := gc.Files(context.Background(), "v1.2.0", func(path string) bool {
+		return strings.HasSuffix(path, ".go")
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]byte{"pkg/lib.go": []byte("package pkg\n")}, files)
+}
+
+func TestCommits(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-z", "--format=%H %s", "v1.2.0..HEAD"})
+
+		return "81918ffc fix: handle empty input\x00\n1ad5c3e7 feat: add parser\x00", nil
+	}
+
+	commits, err := gc.Commits(context.Background(), "v1.2.0", "HEAD")
+	require.NoError(t, err)
+
+	assert.Equal(t, []git.Commit{
+		{Hash: "81918ffc", Subject: "fix: handle empty input"},
+		{Hash: "1ad5c3e7", Subject: "feat: add parser"},
+	}, commits)
+}
+
+func TestMergedTags(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Equal(t, []string{
+			"-C", "/path/to/repo", "for-each-ref", "--merged=main",
+			"--format=%(refname:short)%00%(objectname)%00%(*objectname)%00%(creatordate:unix)", "refs/tags/v[0-9]*",
+		}, args)
+
+		return "v1.0.0\x0081918ffc\x00\x001700000000\nv1.1.0\x00a7c3e19b\x001ad5c3e7\x001700000060", nil
+	}
+
+	tags, err := gc.MergedTags(context.Background(), "main", "v[0-9]*")
+	require.NoError(t, err)
+
+	assert.Equal(t, []git.Tag{
+		{Name: "v1.0.0", Commit: "81918ffc", Date: time.Unix(1700000000, 0).UTC()},
+		{Name: "v1.1.0", Commit: "1ad5c3e7", Date: time.Unix(1700000060, 0).UTC()},
+	}, tags)
+}
+
+func TestMessages(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-z", "--no-merges", "--format=%H %B", "origin/main..HEAD"})
+
+		return "81918ffc fix: handle empty input\n\nRefs: #12\n\x00\n1ad5c3e7 feat: add parser\n\x00", nil
+	}
+
+	messages, err := gc.Messages(context.Background(), "origin/main", "HEAD")
+	require.NoError(t, err)
+
+	assert.Equal(t, []git.Message{
+		{Hash: "81918ffc", Text: "fix: handle empty input\n\nRefs: #12"},
+		{Hash: "1ad5c3e7", Text: "feat: add parser"},
+	}, messages)
+}
+
+func TestChangedFiles(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, args, []string{"-C", "/path/to/repo", "diff", "-z", "--name-only", "v1.2.0", "HEAD"})
+
+		return "README.md\x00pkg/lib.go\x00", nil
+	}
+
+	files, err := gc.ChangedFiles(context.Background(), "v1.2.0", "HEAD")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"README.md", "pkg/lib.go"}, files)
+}
+
+func TestChangedFilesErr(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		return "", errors.New("bad revision")
+	}
+
+	_, err := gc.ChangedFiles(context.Background(), "v1.2.0", "HEAD")
+	assert.EqualError(t, err, "could not list changed files between v1.2.0 and HEAD: bad revision")
+}
+
+func TestRunTimeout(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.Timeout = 10 * time.Millisecond
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		<-ctx.Done()
+		return "", &git.TimeoutError{Args: args}
+	}
+
+	_, err := gc.Run(context.Background(), "fetch", "--tags")
+
+	var timeoutErr *git.TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+
+	assert.EqualError(t, err, "git fetch --tags timed out")
+}
+
+func TestRunDeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := git.NewGit(".").Run(ctx, "version")
+
+	var timeoutErr *git.TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+
+	assert.Contains(t, timeoutErr.Args, "version")
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	isRepo, err := git.NewGit(".").IsRepo(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, isRepo)
+}
+
+func TestError(t *testing.T) {
+	tests := map[string]struct {
+		Stderr   string
+		Sentinel error
+	}{
+		"not a repository": {
+			Stderr:   "fatal: not a git repository (or any of the parent directories): .git",
+			Sentinel: git.ErrNotRepository,
+		},
+		"unknown revision": {
+			Stderr:   "fatal: ambiguous argument 'v9.9.9': unknown revision or path not in the working tree.",
+			Sentinel: git.ErrUnknownRevision,
+		},
+		"no tags": {
+			Stderr:   "fatal: No names found, cannot describe anything.",
+			Sentinel: git.ErrNoTags,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gc := git.NewGit("/path/to/repo")
+			gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+				return "", &git.Error{Args: args, ExitCode: 128, Stderr: test.Stderr}
+			}
+
+			_, err := gc.Commits(context.Background(), "v9.9.9", "HEAD")
+
+			var gitErr *git.Error
+			require.True(t, errors.As(err, &gitErr))
+
+			assert.Equal(t, 128, gitErr.ExitCode)
+			assert.ErrorIs(t, err, test.Sentinel)
+
+			for _, other := range []error{git.ErrNotRepository, git.ErrUnknownRevision, git.ErrNoTags} {
+				if other != test.Sentinel {
+					assert.NotErrorIs(t, err, other)
+				}
+			}
+		})
+	}
+}
+
+func TestGitCmdError(t *testing.T) {
+	_, err := git.NewGit(t.TempDir()).Run(context.Background(), "-C", t.TempDir(), "rev-parse", "HEAD")
+
+	var gitErr *git.Error
+	require.True(t, errors.As(err, &gitErr))
+
+	assert.Equal(t, 128, gitErr.ExitCode)
+	assert.Contains(t, gitErr.Args, "rev-parse")
+	assert.ErrorIs(t, err, git.ErrNotRepository)
+}
+
+func TestMakeSafe(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Equal(t, args, []string{
+			"-c", "safe.directory=/path/to/repo", "-C", "/path/to/repo", "rev-parse", "--is-inside-work-tree",
+		})
+
+		return "true", nil
+	}
+
+	require.NoError(t, gc.MakeSafe(context.Background()))
+
+	isRepo, err := gc.IsRepo(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, isRepo)
+}
+
+func TestIsRepo_NotRepository(t *testing.T) {
+	// Run from inside this repository, the check must look at the
+	// repository directory rather than the working directory.
+	isRepo, err := git.NewGit(t.TempDir()).IsRepo(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, isRepo)
+
+	isRepo, err = git.NewGit("/path/to/missing").IsRepo(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, isRepo)
+}
+
+func TestRunEnv(t *testing.T) {
+	gc := git.NewGit(".")
+
+	out, err := gc.RunEnv(context.Background(), map[string]string{
+		"GIT_COMMITTER_NAME":  "semver",
+		"GIT_COMMITTER_EMAIL": "semver@example.com",
+		"GIT_COMMITTER_DATE":  "1700000000 +0000",
+	}, "var", "GIT_COMMITTER_IDENT")
+	require.NoError(t, err)
+
+	assert.Equal(t, "semver <semver@example.com> 1700000000 +0000\n", out)
+}
+
+// withoutMetadata answers the reads of the version metadata with none and
+// passes the other git commands to cmd.
+func withoutMetadata(
+	cmd func(ctx context.Context, env map[string]string, args ...string) (string, error),
+) func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+	return func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		if len(args) > 2 && (args[2] == "for-each-ref" || args[2] == "log" && args[3] == "--no-walk") {
+			return "", nil
+		}
+
+		return cmd(ctx, env, args...)
+	}
 }
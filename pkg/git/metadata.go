@@ -0,0 +1,219 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// NotesRef is the ref holding the version metadata written as notes.
+const NotesRef = "refs/notes/semver"
+
+// Metadata tells how a version was calculated. It is stored as trailers,
+// e.g. Semver-Bump: minor, in the message of the version tag or in a note of
+// its commit under NotesRef.
+type Metadata struct {
+	Version  string
+	Previous string
+	Bump     string
+	// Source is the merged branch, empty if the version wasn't merged from one.
+	Source string
+	// Rule is what picked the bump, e.g. feature/ into main or bump input major.
+	Rule    string
+	Explain string
+	// Retracted tells why the version was pulled, empty if it wasn't.
+	Retracted string
+}
+
+// metadataField is a trailer key with the field it is stored in.
+type metadataField struct {
+	key   string
+	value *string
+}
+
+// fields returns the trailer keys of the metadata in rendering order.
+func (m *Metadata) fields() []metadataField {
+	return []metadataField{
+		{"Semver-Version", &m.Version},
+		{"Semver-Previous", &m.Previous},
+		{"Semver-Bump", &m.Bump},
+		{"Semver-Source", &m.Source},
+		{"Semver-Rule", &m.Rule},
+		{"Semver-Explain", &m.Explain},
+		{"Semver-Retracted", &m.Retracted},
+	}
+}
+
+// String renders the metadata as trailers, leaving out empty fields.
+func (m Metadata) String() string {
+	var lines []string
+
+	for _, field := range m.fields() {
+		if *field.value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", field.key, *field.value))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ParseMetadata returns the metadata of version from text, a tag message or
+// a note. Notes hold one paragraph of trailers per version of the commit,
+// and later paragraphs of a version, e.g. a retraction, add to earlier ones.
+func ParseMetadata(text, version string) (Metadata, bool) {
+	var (
+		result Metadata
+		found  bool
+	)
+
+	for _, m := range parseMetadataParagraphs(text) {
+		if m.Version != version {
+			continue
+		}
+
+		found = true
+
+		fields := result.fields()
+		for i, field := range m.fields() {
+			if *field.value != "" {
+				*fields[i].value = *field.value
+			}
+		}
+	}
+
+	return result, found
+}
+
+// parseMetadataParagraphs returns the metadata of each paragraph of text
+// naming a version.
+func parseMetadataParagraphs(text string) []Metadata {
+	var result []Metadata
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		var m Metadata
+
+		for _, line := range strings.Split(paragraph, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+
+			for _, field := range m.fields() {
+				if strings.EqualFold(strings.TrimSpace(key), field.key) {
+					*field.value = strings.TrimSpace(value)
+				}
+			}
+		}
+
+		if m.Version != "" {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// TagMetadata returns the metadata of tag, read from its annotation or else
+// from the note of its commit.
+func (c *Client) TagMetadata(ctx context.Context, tag string) (Metadata, error) {
+	message, err := c.Run(ctx, "-C", c.repoDir, "for-each-ref", "--format=%(contents)", "refs/tags/"+tag)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("could not read tag %s: %w", tag, err)
+	}
+
+	if m, ok := ParseMetadata(message, tag); ok {
+		return m, nil
+	}
+
+	// A missing note fails, like a missing tag.
+	note, err := c.Run(ctx, "-C", c.repoDir, "notes", "--ref="+NotesRef, "show", tag+"^{commit}")
+	if err != nil {
+		return Metadata{}, fmt.Errorf("%w for %s", ErrNoMetadata, tag)
+	}
+
+	if m, ok := ParseMetadata(note, tag); ok {
+		return m, nil
+	}
+
+	return Metadata{}, fmt.Errorf("%w for %s", ErrNoMetadata, tag)
+}
+
+// Retractions returns the retracted tags with the reason, read from the
+// Semver-Retracted trailers of tag annotations and of the notes of tagged
+// commits. They are read once, until the client writes metadata.
+func (c *Client) Retractions(ctx context.Context) (map[string]string, error) {
+	if c.recorded != nil {
+		return c.recorded, nil
+	}
+
+	messages, err := c.Run(ctx, "-C", c.repoDir, "for-each-ref", "--format=%(contents)%00", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("could not read tag messages: %w", err)
+	}
+
+	notes, err := c.Run(ctx, "-C", c.repoDir, "log", "--no-walk", "--tags", "--notes="+NotesRef, "--format=%N%x00")
+	if err != nil {
+		return nil, fmt.Errorf("could not read notes: %w", err)
+	}
+
+	result := make(map[string]string)
+
+	for _, text := range strings.Split(messages+"\x00"+notes, "\x00") {
+		for _, m := range parseMetadataParagraphs(text) {
+			if m.Retracted != "" {
+				result[m.Version] = m.Retracted
+			}
+		}
+	}
+
+	c.recorded = result
+
+	return result, nil
+}
+
+// AnnotateTag creates the annotated tag at rev with message.
+func (c *Client) AnnotateTag(ctx context.Context, tag, rev, message string) error {
+	c.recorded = nil
+
+	_, err := c.RunEnv(ctx, c.identity(ctx), "-C", c.repoDir, "tag", "-a", "-m", message, tag, rev)
+	if err != nil {
+		return fmt.Errorf("could not create tag %s: %w", tag, err)
+	}
+
+	return nil
+}
+
+// AddNote appends message to the note of rev under NotesRef, keeping the
+// metadata of other versions of the commit.
+func (c *Client) AddNote(ctx context.Context, rev, message string) error {
+	c.recorded = nil
+
+	_, err := c.RunEnv(ctx, c.identity(ctx), "-C", c.repoDir, "notes", "--ref="+NotesRef, "append", "-m", message, rev)
+	if err != nil {
+		return fmt.Errorf("could not add note to %s: %w", rev, err)
+	}
+
+	return nil
+}
+
+// identity returns the env vars naming the author of tags and notes, unless
+// git has a user configured, as runners usually don't.
+func (c *Client) identity(ctx context.Context) map[string]string {
+	if email, err := c.Run(ctx, "-C", c.repoDir, "config", "user.email"); err == nil && strings.TrimSpace(email) != "" {
+		return nil
+	}
+
+	const (
+		name  = "github-actions[bot]"
+		email = "41898282+github-actions[bot]@users.noreply.github.com"
+	)
+
+	return map[string]string{
+		"GIT_AUTHOR_NAME":     name,
+		"GIT_AUTHOR_EMAIL":    email,
+		"GIT_COMMITTER_NAME":  name,
+		"GIT_COMMITTER_EMAIL": email,
+	}
+}
@@ -0,0 +1,150 @@
+package git_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/git"
+	"github.com/snapfi/semver-action/pkg/gittest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata_String(t *testing.T) {
+	m := git.Metadata{
+		Version:  "v1.3.0",
+		Previous: "v1.2.3",
+		Bump:     "minor",
+		Source:   "feature/search",
+		Rule:     "feature/ into main",
+	}
+
+	assert.Equal(t, "Semver-Version: v1.3.0\nSemver-Previous: v1.2.3\nSemver-Bump: minor\n"+
+		"Semver-Source: feature/search\nSemver-Rule: feature/ into main", m.String())
+}
+
+func TestParseMetadata(t *testing.T) {
+	note := "Semver-Version: v1.3.0-pre.1\nSemver-Bump: minor\n\n" +
+		"Semver-Version: v1.3.0\r\nsemver-previous: v1.3.0-pre.1\r\nSemver-Bump: finalize"
+
+	m, ok := git.ParseMetadata(note, "v1.3.0")
+	require.True(t, ok)
+
+	assert.Equal(t, git.Metadata{Version: "v1.3.0", Previous: "v1.3.0-pre.1", Bump: "finalize"}, m)
+
+	_, ok = git.ParseMetadata(note, "v1.2.0")
+	assert.False(t, ok)
+
+	_, ok = git.ParseMetadata("Release v1.2.0\n\nNo trailers here.", "v1.2.0")
+	assert.False(t, ok)
+
+	// A later paragraph retracts the version.
+	m, ok = git.ParseMetadata(note+"\n\nSemver-Version: v1.3.0\nSemver-Retracted: broke login", "v1.3.0")
+	require.True(t, ok)
+
+	assert.Equal(t, git.Metadata{
+		Version:   "v1.3.0",
+		Previous:  "v1.3.0-pre.1",
+		Bump:      "finalize",
+		Retracted: "broke login",
+	}, m)
+}
+
+func TestTagMetadata(t *testing.T) {
+	r := gittest.New(t)
+	r.Commit("initial commit")
+	r.Tag("v1.0.0")
+	r.Commit("add search")
+
+	ctx := context.Background()
+	gc := git.NewGit(r.Dir)
+
+	annotated := git.Metadata{Version: "v1.1.0", Previous: "v1.0.0", Bump: "minor", Rule: "bump input minor"}
+	require.NoError(t, gc.AnnotateTag(ctx, "v1.1.0", "HEAD", "Release v1.1.0\n\n"+annotated.String()))
+
+	// Both versions of the commit share its note.
+	noted := git.Metadata{Version: "v1.1.0-pre.1", Previous: "v1.0.0", Bump: "minor"}
+	require.NoError(t, gc.AddNote(ctx, "HEAD", noted.String()))
+	require.NoError(t, gc.AddNote(ctx, "HEAD", git.Metadata{Version: "v1.1.0-pre.2", Bump: "prerelease"}.String()))
+	r.Tag("v1.1.0-pre.1")
+
+	for _, gc := range []interface {
+		TagMetadata(ctx context.Context, tag string) (git.Metadata, error)
+	}{gc, git.NewSnapshot(gc)} {
+		m, err := gc.TagMetadata(ctx, "v1.1.0")
+		require.NoError(t, err)
+
+		assert.Equal(t, annotated, m)
+
+		m, err = gc.TagMetadata(ctx, "v1.1.0-pre.1")
+		require.NoError(t, err)
+
+		assert.Equal(t, noted, m)
+
+		_, err = gc.TagMetadata(ctx, "v1.0.0")
+		assert.ErrorIs(t, err, git.ErrNoMetadata)
+
+		_, err = gc.TagMetadata(ctx, "v9.9.9")
+		assert.ErrorIs(t, err, git.ErrNoMetadata)
+	}
+}
+
+func TestRetractions(t *testing.T) {
+	r := gittest.New(t)
+
+	ctx := context.Background()
+	gc := git.NewGit(r.Dir)
+
+	r.Commit("initial commit")
+
+	retractions, err := gc.Retractions(ctx)
+	require.NoError(t, err)
+
+	assert.Empty(t, retractions)
+
+	r.AnnotatedTag("v1.0.0", "Release v1.0.0\n\nSemver-Version: v1.0.0\nSemver-Retracted: broke login")
+	r.Commit("fix login")
+	r.Tag("v1.0.1")
+	r.Commit("add search")
+	r.Tag("v1.1.0")
+
+	require.NoError(t, gc.AddNote(ctx, "v1.1.0", "Semver-Version: v1.1.0\nSemver-Retracted: slow search"))
+	require.NoError(t, gc.AddNote(ctx, "v1.0.1", "Semver-Version: v1.0.1\nSemver-Bump: patch"))
+
+	retractions, err = gc.Retractions(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"v1.0.0": "broke login", "v1.1.0": "slow search"}, retractions)
+}
+
+func TestRetractions_ReadBack(t *testing.T) {
+	r := gittest.New(t)
+	r.Commit("initial commit")
+	r.Tag("v1.0.0")
+	r.Commit("fix login")
+	r.Tag("v1.0.1")
+	r.Commit("add search")
+	r.AnnotatedTag("v1.1.0", "Release v1.1.0\n\nSemver-Version: v1.1.0\nSemver-Retracted: slow search")
+
+	ctx := context.Background()
+	gc := git.NewGit(r.Dir)
+
+	require.NoError(t, gc.AddNote(ctx, "v1.0.1", "Semver-Version: v1.0.1\nSemver-Retracted: broke login"))
+
+	// Neither client is told about the retractions, they read them back.
+	for _, gc := range []interface {
+		LatestTag(ctx context.Context) (string, error)
+		AncestorTag(ctx context.Context, include, exclude, branch string) (string, error)
+	}{gc, git.NewSnapshot(git.NewGit(r.Dir))} {
+		tag, err := gc.LatestTag(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, "v1.0.0", tag)
+
+		tag, err = gc.AncestorTag(ctx, "v[0-9]*", "", "HEAD")
+		require.NoError(t, err)
+
+		assert.Equal(t, "v1.0.0", tag)
+	}
+}
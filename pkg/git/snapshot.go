@@ -0,0 +1,690 @@
+package git
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+const (
+	// maxDescribeCandidates is the number of tags git describe considers.
+	maxDescribeCandidates = 10
+
+	// DefaultSnapshotDepth is the number of commits of HEAD a snapshot loads.
+	DefaultSnapshotDepth = 5000
+)
+
+type (
+	// Snapshot answers the queries of a Client from refs and the recent
+	// history of HEAD, loaded with a few bulk git commands on first use.
+	// Queries that reach past the loaded history, or all queries if loading
+	// fails, are passed to the Client.
+	Snapshot struct {
+		// Depth is the number of commits of HEAD to load, all when zero.
+		Depth int
+
+		client *Client
+		loaded bool
+		// complete is set when the whole history of HEAD is loaded.
+		complete bool
+
+		head    string
+		branch  string
+		commits map[string]*snapshotCommit
+		tags    map[string]*snapshotTag
+		// tagsAt maps a commit to its tags, sorted by name as listed by for-each-ref.
+		tagsAt   map[string][]*snapshotTag
+		branches map[string]string
+	}
+
+	snapshotCommit struct {
+		hash    string
+		parents []string
+		date    int64
+		subject string
+	}
+
+	snapshotTag struct {
+		name      string
+		commit    string
+		annotated bool
+		// date is the tagger date of annotated tags.
+		date       int64
+		commitDate int64
+	}
+)
+
+// NewSnapshot returns a snapshot of the repository of the client.
+func NewSnapshot(client *Client) *Snapshot {
+	return &Snapshot{client: client, Depth: DefaultSnapshotDepth}
+}
+
+// load reads refs and the recent history of HEAD, once.
+func (s *Snapshot) load(ctx context.Context) bool {
+	if s.loaded {
+		return s.commits != nil
+	}
+
+	s.loaded = true
+
+	if err := s.loadRefs(ctx); err != nil {
+		log.Debugf("git snapshot disabled: %s", err)
+		return false
+	}
+
+	// Tags are matched against the retractions of the metadata in memory.
+	if _, err := s.client.Retractions(ctx); err != nil {
+		log.Debugf("git snapshot disabled: %s", err)
+		return false
+	}
+
+	if err := s.loadHistory(ctx); err != nil {
+		log.Debugf("git snapshot disabled: %s", err)
+		s.commits = nil
+
+		return false
+	}
+
+	log.Debugf("git snapshot: %d commits, %d tags", len(s.commits), len(s.tags))
+
+	return true
+}
+
+func (s *Snapshot) loadRefs(ctx context.Context) error {
+	out, err := s.client.Run(ctx, "-C", s.client.repoDir, "rev-parse", "HEAD", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return fmt.Errorf("could not resolve HEAD: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		return fmt.Errorf("unexpected rev-parse output: %q", out)
+	}
+
+	s.head, s.branch = lines[0], lines[1]
+
+	out, err = s.client.Run(ctx, "-C", s.client.repoDir, "for-each-ref",
+		"--format=%(refname)%00%(objecttype)%00%(objectname)%00%(*objectname)"+
+			"%00%(creatordate:unix)%00%(committerdate:unix)%00%(*committerdate:unix)",
+		"refs/tags", "refs/heads")
+	if err != nil {
+		return fmt.Errorf("could not list refs: %w", err)
+	}
+
+	s.tags = make(map[string]*snapshotTag)
+	s.tagsAt = make(map[string][]*snapshotTag)
+	s.branches = make(map[string]string)
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 7 {
+			continue
+		}
+
+		ref, kind, object, peeled := fields[0], fields[1], fields[2], fields[3]
+
+		if name := strings.TrimPrefix(ref, "refs/heads/"); name != ref {
+			s.branches[name] = object
+			continue
+		}
+
+		tag := &snapshotTag{name: strings.TrimPrefix(ref, "refs/tags/")}
+
+		switch kind {
+		case "commit":
+			tag.commit = object
+			tag.commitDate, _ = strconv.ParseInt(fields[5], 10, 64)
+		case "tag":
+			tag.commit = peeled
+			tag.annotated = true
+			tag.date, _ = strconv.ParseInt(fields[4], 10, 64)
+			tag.commitDate, _ = strconv.ParseInt(fields[6], 10, 64)
+		}
+
+		s.tags[tag.name] = tag
+
+		// Tags of trees, blobs or nested tags can't describe a commit.
+		if tag.commit != "" && tag.commitDate != 0 {
+			s.tagsAt[tag.commit] = append(s.tagsAt[tag.commit], tag)
+		}
+	}
+
+	return nil
+}
+
+func (s *Snapshot) loadHistory(ctx context.Context) error {
+	args := []string{"-C", s.client.repoDir, "log", "-z", "--format=%H%x1f%P%x1f%ct%x1f%s"}
+	if s.Depth > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", s.Depth))
+	}
+
+	out, err := s.client.Run(ctx, append(args, "HEAD")...)
+	if err != nil {
+		return fmt.Errorf("could not read history: %w", err)
+	}
+
+	s.commits = make(map[string]*snapshotCommit)
+
+	for _, record := range strings.Split(out, "\x00") {
+		fields := strings.SplitN(strings.TrimPrefix(record, "\n"), "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+
+		c := &snapshotCommit{
+			hash:    fields[0],
+			parents: strings.Fields(fields[1]),
+			subject: fields[3],
+		}
+		c.date, _ = strconv.ParseInt(fields[2], 10, 64)
+
+		s.commits[c.hash] = c
+	}
+
+	if s.commits[s.head] == nil {
+		return fmt.Errorf("HEAD %s missing from history", s.head)
+	}
+
+	s.complete = s.Depth <= 0 || len(s.commits) < s.Depth
+
+	return nil
+}
+
+// resolve returns the commit of rev if it is in the snapshot, or an empty string.
+func (s *Snapshot) resolve(rev string) string {
+	var hash string
+
+	switch {
+	case rev == "HEAD" || rev == s.branch:
+		hash = s.head
+	case s.tags[rev] != nil:
+		hash = s.tags[rev].commit
+	case s.branches[rev] != "":
+		hash = s.branches[rev]
+	case len(rev) >= 4:
+		for h := range s.commits {
+			if strings.HasPrefix(h, rev) {
+				if hash != "" {
+					// Ambiguous abbreviation.
+					return ""
+				}
+
+				hash = h
+			}
+		}
+	}
+
+	if s.commits[hash] == nil {
+		return ""
+	}
+
+	return hash
+}
+
+// MakeSafe marks the repository as safe.directory for the git commands of the client.
+func (s *Snapshot) MakeSafe(ctx context.Context) error {
+	return s.client.MakeSafe(ctx)
+}
+
+// IsRepo returns true if the repository directory is in a git work tree.
+func (s *Snapshot) IsRepo(ctx context.Context) (bool, error) {
+	return s.client.IsRepo(ctx)
+}
+
+// CurrentBranch returns the current branch checked out.
+func (s *Snapshot) CurrentBranch(ctx context.Context) (string, error) {
+	if !s.load(ctx) {
+		return s.client.CurrentBranch(ctx)
+	}
+
+	return s.branch, nil
+}
+
+// SourceBranch tries to get branch from the subject of the commit, where
+// pull request merges name it.
+func (s *Snapshot) SourceBranch(ctx context.Context, commitHash string) (string, error) {
+	if !s.load(ctx) || s.resolve(commitHash) == "" {
+		return s.client.SourceBranch(ctx, commitHash)
+	}
+
+	return sourceBranchFromMessage(s.commits[s.resolve(commitHash)].subject)
+}
+
+// LatestTag returns the tag of the most recently committed tagged commit, like
+// git rev-list --tags --max-count=1 with git describe --tags.
+func (s *Snapshot) LatestTag(ctx context.Context) (string, error) {
+	if !s.load(ctx) {
+		return s.client.LatestTag(ctx)
+	}
+
+	var latest *snapshotTag
+
+	for _, tag := range s.tags {
+		if tag.commitDate == 0 || s.client.isRetracted(tag.name) {
+			continue
+		}
+
+		if latest == nil || tag.commitDate > latest.commitDate ||
+			(tag.commitDate == latest.commitDate && tag.name < latest.name) {
+			latest = tag
+		}
+	}
+
+	if latest == nil {
+		return "", nil
+	}
+
+	if best := s.bestTag(s.tagsAt[latest.commit], "", ""); best != nil {
+		return best.name, nil
+	}
+
+	return "", nil
+}
+
+// AncestorTag returns the previous tag that matches specific pattern if found,
+// like git describe --tags --abbrev=0 --match include --exclude exclude.
+func (s *Snapshot) AncestorTag(ctx context.Context, include, exclude, branch string) (string, error) {
+	start := ""
+	if s.load(ctx) {
+		start = s.resolve(branch)
+	}
+
+	if start == "" {
+		return s.client.AncestorTag(ctx, include, exclude, branch)
+	}
+
+	tag, _, ok := s.describe(start, include, exclude)
+
+	switch {
+	case !ok, tag == "" && !s.complete:
+		return s.client.AncestorTag(ctx, include, exclude, branch)
+	case tag != "":
+		return tag, nil
+	}
+
+	// The most recent root commit, like git rev-list --max-parents=0 HEAD.
+	var root *snapshotCommit
+
+	for _, c := range s.commits {
+		if len(c.parents) == 0 && (root == nil || c.date > root.date) {
+			root = c
+		}
+	}
+
+	if root == nil {
+		return "", nil
+	}
+
+	return root.hash, nil
+}
+
+// describe returns the name of the tag nearest to start and the number of
+// commits since it, following the algorithm of git describe: commits are
+// walked by date and the candidate tag with the fewest commits not contained
+// in it wins. It returns false if the walk reaches past the loaded history.
+func (s *Snapshot) describe(start, include, exclude string) (string, int, bool) {
+	type candidate struct {
+		tag   *snapshotTag
+		depth int
+		flag  uint
+	}
+
+	var (
+		candidates []*candidate
+		flags      = map[string]uint{start: 1}
+		queue      = &commitQueue{}
+		seen       int
+		gaveUpOn   *snapshotCommit
+	)
+
+	// push queues the parents of c, which inherit its flags.
+	push := func(c *snapshotCommit) bool {
+		for _, p := range c.parents {
+			parent := s.commits[p]
+			if parent == nil {
+				if !s.complete {
+					return false
+				}
+
+				continue
+			}
+
+			if _, ok := flags[p]; !ok {
+				heap.Push(queue, parent)
+			}
+
+			flags[p] |= flags[c.hash]
+		}
+
+		return true
+	}
+
+	heap.Push(queue, s.commits[start])
+
+	for queue.Len() > 0 {
+		c := heap.Pop(queue).(*snapshotCommit)
+		seen++
+
+		if tag := s.bestTag(s.tagsAt[c.hash], include, exclude); tag != nil {
+			if len(candidates) == maxDescribeCandidates {
+				gaveUpOn = c
+				break
+			}
+
+			cand := &candidate{tag: tag, depth: seen - 1, flag: 1 << (len(candidates) + 1)}
+			candidates = append(candidates, cand)
+			flags[c.hash] |= cand.flag
+		}
+
+		for _, cand := range candidates {
+			if flags[c.hash]&cand.flag == 0 {
+				cand.depth++
+			}
+		}
+
+		if !push(c) {
+			return "", 0, false
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", 0, true
+	}
+
+	best := candidates[0]
+
+	for _, cand := range candidates[1:] {
+		if cand.depth < best.depth {
+			best = cand
+		}
+	}
+
+	// Like finish_depth_computation of git describe, the walk stopped at the
+	// candidate limit goes on until every queued commit is contained in the
+	// best tag, counting the commits that aren't.
+	if gaveUpOn != nil {
+		heap.Push(queue, gaveUpOn)
+	}
+
+	for queue.Len() > 0 {
+		c := heap.Pop(queue).(*snapshotCommit)
+
+		if flags[c.hash]&best.flag != 0 {
+			if queue.within(flags, best.flag) {
+				break
+			}
+		} else {
+			best.depth++
+		}
+
+		if !push(c) {
+			return "", 0, false
+		}
+	}
+
+	return best.tag.name, best.depth, true
+}
+
+// bestTag returns the tag git describe names a commit by among the matching
+// tags: annotated tags before lightweight ones, newer annotated tags first.
+// An empty include matches every tag.
+func (s *Snapshot) bestTag(tags []*snapshotTag, include, exclude string) *snapshotTag {
+	var best *snapshotTag
+
+	for _, tag := range tags {
+		if (include != "" && !matchPattern(include, tag.name)) || (exclude != "" && matchPattern(exclude, tag.name)) ||
+			s.client.isRetracted(tag.name) {
+			continue
+		}
+
+		switch {
+		case best == nil:
+			best = tag
+		case tag.annotated && !best.annotated:
+			best = tag
+		case tag.annotated && best.annotated && tag.date > best.date:
+			best = tag
+		}
+	}
+
+	return best
+}
+
+// matchPattern matches like git's wildmatch, where * also matches /.
+func matchPattern(pattern, name string) bool {
+	ok, _ := path.Match(strings.ReplaceAll(pattern, "/", "\x00"), strings.ReplaceAll(name, "/", "\x00"))
+	return ok
+}
+
+// TagExists returns true if the given tag exists.
+func (s *Snapshot) TagExists(ctx context.Context, tag string) (bool, error) {
+	if !s.load(ctx) {
+		return s.client.TagExists(ctx, tag)
+	}
+
+	return s.tags[tag] != nil, nil
+}
+
+// IsAncestor returns true if ancestor is reachable from commit.
+func (s *Snapshot) IsAncestor(ctx context.Context, ancestor, commit string) (bool, error) {
+	var from, to string
+	if s.load(ctx) {
+		from, to = s.resolve(ancestor), s.resolve(commit)
+	}
+
+	if from == "" || to == "" {
+		return s.client.IsAncestor(ctx, ancestor, commit)
+	}
+
+	visited := map[string]bool{to: true}
+	stack := []string{to}
+
+	for len(stack) > 0 {
+		c := s.commits[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		if c.hash == from {
+			return true, nil
+		}
+
+		for _, p := range c.parents {
+			switch {
+			case visited[p]:
+			case s.commits[p] != nil:
+				visited[p] = true
+				stack = append(stack, p)
+			case !s.complete:
+				return s.client.IsAncestor(ctx, ancestor, commit)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Commits returns the commits reachable from to but not from from, newest
+// first. Like git rev-list, both histories are walked by date until only
+// commits reachable from from are left to visit.
+func (s *Snapshot) Commits(ctx context.Context, from, to string) ([]Commit, error) {
+	var start, end string
+	if s.load(ctx) {
+		start, end = s.resolve(from), s.resolve(to)
+	}
+
+	if start == "" || end == "" {
+		return s.client.Commits(ctx, from, to)
+	}
+
+	var (
+		excluded    = map[string]bool{start: true}
+		queued      = map[string]bool{start: true, end: true}
+		interesting = 0
+		queue       = &commitQueue{}
+		walked      []*snapshotCommit
+	)
+
+	heap.Push(queue, s.commits[start])
+
+	if !excluded[end] {
+		heap.Push(queue, s.commits[end])
+		interesting++
+	}
+
+	for interesting > 0 {
+		c := heap.Pop(queue).(*snapshotCommit)
+		queued[c.hash] = false
+
+		if !excluded[c.hash] {
+			interesting--
+			walked = append(walked, c)
+		}
+
+		for _, p := range c.parents {
+			parent := s.commits[p]
+			if parent == nil {
+				if !s.complete {
+					return s.client.Commits(ctx, from, to)
+				}
+
+				continue
+			}
+
+			_, seen := queued[p]
+
+			if excluded[c.hash] && !excluded[p] {
+				excluded[p] = true
+
+				if queued[p] {
+					interesting--
+				}
+			}
+
+			if !seen {
+				queued[p] = true
+				heap.Push(queue, parent)
+
+				if !excluded[p] {
+					interesting++
+				}
+			}
+		}
+	}
+
+	var commits []Commit
+
+	for _, c := range walked {
+		if !excluded[c.hash] {
+			commits = append(commits, Commit{Hash: c.hash, Subject: c.subject})
+		}
+	}
+
+	return commits, nil
+}
+
+// ChangedFiles returns the paths of the files changed between from and to.
+func (s *Snapshot) ChangedFiles(ctx context.Context, from, to string) ([]string, error) {
+	return s.client.ChangedFiles(ctx, from, to)
+}
+
+// Describe returns the nearest tag matching include reachable from rev with
+// the commits since it. It asks the client if rev has no tag in the snapshot
+// history, and for the abbreviated hash, which must be unique among all
+// objects.
+func (s *Snapshot) Describe(ctx context.Context, include, rev string) (Description, error) {
+	start := ""
+	if s.load(ctx) {
+		start = s.resolve(rev)
+	}
+
+	if start == "" {
+		return s.client.Describe(ctx, include, rev)
+	}
+
+	tag, distance, ok := s.describe(start, include, "")
+	if !ok || tag == "" {
+		return s.client.Describe(ctx, include, rev)
+	}
+
+	hash, err := s.client.Clean(s.client.Run(ctx, "-C", s.client.repoDir, "rev-parse", "--short=7", start))
+	if err != nil {
+		return Description{}, fmt.Errorf("could not abbreviate %s: %w", rev, err)
+	}
+
+	return Description{Tag: tag, Distance: distance, Hash: hash}, nil
+}
+
+// CommitMessage returns the full message of the commit at rev. The snapshot
+// only loads subjects, so it asks the client.
+func (s *Snapshot) CommitMessage(ctx context.Context, rev string) (string, error) {
+	return s.client.CommitMessage(ctx, rev)
+}
+
+// TagMetadata returns the metadata of tag. The snapshot doesn't load tag
+// messages or notes, so it asks the client.
+func (s *Snapshot) TagMetadata(ctx context.Context, tag string) (Metadata, error) {
+	return s.client.TagMetadata(ctx, tag)
+}
+
+// Messages returns the messages of the commits between from and to. The
+// snapshot doesn't load messages, so it asks the client.
+func (s *Snapshot) Messages(ctx context.Context, from, to string) ([]Message, error) {
+	return s.client.Messages(ctx, from, to)
+}
+
+// Files returns the content of every file matching match at the given
+// revision, keyed by path.
+func (s *Snapshot) Files(ctx context.Context, rev string, match func(path string) bool) (map[string][]byte, error) {
+	return s.client.Files(ctx, rev, match)
+}
+
+// commitQueue orders commits by date, newest first, then by insertion.
+type commitQueue struct {
+	items []*snapshotCommit
+	order []int
+	next  int
+}
+
+func (q *commitQueue) Len() int { return len(q.items) }
+
+// within returns true if every queued commit has flag set.
+func (q *commitQueue) within(flags map[string]uint, flag uint) bool {
+	for _, c := range q.items {
+		if flags[c.hash]&flag == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (q *commitQueue) Less(i, j int) bool {
+	if q.items[i].date != q.items[j].date {
+		return q.items[i].date > q.items[j].date
+	}
+
+	return q.order[i] < q.order[j]
+}
+
+func (q *commitQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.order[i], q.order[j] = q.order[j], q.order[i]
+}
+
+func (q *commitQueue) Push(x interface{}) {
+	q.items = append(q.items, x.(*snapshotCommit))
+	q.order = append(q.order, q.next)
+	q.next++
+}
+
+func (q *commitQueue) Pop() interface{} {
+	n := len(q.items) - 1
+	item := q.items[n]
+	q.items, q.order = q.items[:n], q.order[:n]
+
+	return item
+}
@@ -0,0 +1,470 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/git"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildRepo creates a repository on main with n commits, a merged pull request
+// every 7 commits, commits sharing a date every 5 and a release tag every
+// tagEvery commits, followed by a prerelease tag.
+func buildRepo(tb testing.TB, n, tagEvery int) string {
+	tb.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		tb.Skip("git not found")
+	}
+
+	const base = 1700000000
+
+	var stream bytes.Buffer
+
+	data := func(s string) {
+		fmt.Fprintf(&stream, "data %d\n%s\n", len(s), s)
+	}
+
+	date := func(i int) int {
+		if i%5 == 0 {
+			i--
+		}
+
+		return base + i*60
+	}
+
+	for i := 1; i <= n; i++ {
+		message := fmt.Sprintf("commit %d", i)
+
+		if i%7 == 0 {
+			fmt.Fprintf(&stream, "commit refs/heads/feature\nmark :%d\n", n+i)
+			fmt.Fprintf(&stream, "committer Dev <dev@example.com> %d +0000\n", date(i)-30)
+			data(fmt.Sprintf("feature %d", i))
+			fmt.Fprintf(&stream, "from :%d\nM 644 inline feature.txt\n", i-1)
+			data(fmt.Sprint(i))
+
+			message = fmt.Sprintf("Merge pull request #%d from org/feature/some-%d", i, i)
+		}
+
+		fmt.Fprintf(&stream, "commit refs/heads/main\nmark :%d\n", i)
+		fmt.Fprintf(&stream, "committer Dev <dev@example.com> %d +0000\n", date(i))
+		data(message)
+
+		if i%7 == 0 {
+			fmt.Fprintf(&stream, "merge :%d\n", n+i)
+		}
+
+		stream.WriteString("M 644 inline main.txt\n")
+		data(fmt.Sprint(i))
+
+		if i%tagEvery != 0 {
+			continue
+		}
+
+		release := i / tagEvery
+
+		switch release % 3 {
+		case 0:
+			fmt.Fprintf(&stream, "tag v%d.0.0\nfrom :%d\n", release, i)
+			fmt.Fprintf(&stream, "tagger Dev <dev@example.com> %d +0000\n", date(i)+1)
+			data("release")
+		case 1:
+			// A lightweight and an annotated tag on the same commit.
+			fmt.Fprintf(&stream, "reset refs/tags/v%d.0.0\nfrom :%d\n\n", release, i)
+			fmt.Fprintf(&stream, "tag v%d.0.0-rc\nfrom :%d\n", release, i)
+			fmt.Fprintf(&stream, "tagger Dev <dev@example.com> %d +0000\n", date(i)+1)
+			data("release candidate")
+		default:
+			fmt.Fprintf(&stream, "reset refs/tags/v%d.0.0\nfrom :%d\n\n", release, i)
+		}
+
+		if i > 1 {
+			fmt.Fprintf(&stream, "reset refs/tags/v%d.0.0-pre.1\nfrom :%d\n\n", release, i-1)
+		}
+	}
+
+	dir := tb.TempDir()
+
+	run := func(stdin *bytes.Buffer, args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if stdin != nil {
+			cmd.Stdin = stdin
+		}
+
+		out, err := cmd.CombinedOutput()
+		require.NoError(tb, err, string(out))
+	}
+
+	run(nil, "init", "-q")
+	run(&stream, "fast-import", "--quiet")
+	run(nil, "symbolic-ref", "HEAD", "refs/heads/main")
+	run(nil, "reset", "-q", "--hard")
+
+	return dir
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := buildRepo(t, 300, 20)
+
+	tests := map[string]struct {
+		depth         int
+		expectedCalls int
+	}{
+		// HEAD, refs, retractions and history.
+		"whole history": {depth: 0, expectedCalls: 5},
+		"default depth": {depth: git.DefaultSnapshotDepth, expectedCalls: 5},
+		// Queries reaching past the recent commits go to git.
+		"recent history": {depth: 40},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			var calls int
+
+			snapshot := git.NewSnapshot(countedGit(dir, &calls))
+			snapshot.Depth = tc.depth
+
+			assertSnapshot(t, dir, git.NewGit(dir), snapshot)
+
+			if tc.expectedCalls > 0 {
+				assert.Equal(t, tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+// assertSnapshot compares the answers of snapshot to those of client.
+func assertSnapshot(t *testing.T, dir string, client *git.Client, snapshot *git.Snapshot) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	clientBranch, err := client.CurrentBranch(ctx)
+	require.NoError(t, err)
+
+	snapshotBranch, err := snapshot.CurrentBranch(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, clientBranch, snapshotBranch)
+	clientTag, err := client.LatestTag(ctx)
+	require.NoError(t, err)
+
+	snapshotTag, err := snapshot.LatestTag(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, clientTag, snapshotTag)
+
+	revs := []string{"main", "HEAD", "v3.0.0", "v7.0.0-rc", "v10.0.0-pre.1", "feature"}
+
+	patterns := [][2]string{
+		{"v[0-9]*", ""},
+		{"v[0-9]*-*", ""},
+		{"v[0-9]*", "v[0-9]*-*"},
+		{"v1[0-9].*", ""},
+		{"x*", ""},
+	}
+
+	for _, rev := range revs {
+		for _, p := range patterns {
+			clientTag, err := client.AncestorTag(ctx, p[0], p[1], rev)
+			require.NoError(t, err)
+
+			snapshotTag, err := snapshot.AncestorTag(ctx, p[0], p[1], rev)
+			require.NoError(t, err)
+
+			assert.Equal(t, clientTag, snapshotTag, "ancestor tag of %s matching %q excluding %q", rev, p[0], p[1])
+		}
+	}
+
+	for _, tag := range []string{"v1.0.0", "v1.0.0-rc", "v99.0.0"} {
+		clientExists, err := client.TagExists(ctx, tag)
+		require.NoError(t, err)
+
+		snapshotExists, err := snapshot.TagExists(ctx, tag)
+		require.NoError(t, err)
+
+		assert.Equal(t, clientExists, snapshotExists, tag)
+	}
+
+	for _, pair := range [][2]string{{"v2.0.0", "HEAD"}, {"HEAD", "v2.0.0"}, {"v4.0.0-pre.1", "v4.0.0"}} {
+		clientIsAncestor, err := client.IsAncestor(ctx, pair[0], pair[1])
+		require.NoError(t, err)
+
+		snapshotIsAncestor, err := snapshot.IsAncestor(ctx, pair[0], pair[1])
+		require.NoError(t, err)
+
+		assert.Equal(t, clientIsAncestor, snapshotIsAncestor, "%s is ancestor of %s", pair[0], pair[1])
+	}
+
+	for _, from := range []string{"v12.0.0", "v9.0.0-pre.1", "v13.0.0-rc", "HEAD"} {
+		clientCommits, err := client.Commits(ctx, from, "HEAD")
+		require.NoError(t, err)
+
+		snapshotCommits, err := snapshot.Commits(ctx, from, "HEAD")
+		require.NoError(t, err)
+
+		assert.Equal(t, clientCommits, snapshotCommits, "commits since %s", from)
+	}
+
+	head, err := client.Run(ctx, "-C", dir, "rev-parse", "main~2")
+	require.NoError(t, err)
+
+	for _, rev := range []string{"HEAD", head[:10]} {
+		clientSource, clientErr := client.SourceBranch(ctx, rev)
+		snapshotSource, snapshotErr := snapshot.SourceBranch(ctx, rev)
+
+		assert.Equal(t, clientSource, snapshotSource)
+		assert.Equal(t, clientErr, snapshotErr)
+	}
+}
+
+// buildTopicRepo creates a repository on main with a tagged topic branch
+// merged every other commit, more tags than git describe considers, and a
+// long untagged branch, interleaved by date, merged at the end.
+func buildTopicRepo(tb testing.TB) string {
+	tb.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		tb.Skip("git not found")
+	}
+
+	const (
+		base   = 1700000000
+		topics = 14
+		long   = 25
+	)
+
+	var stream bytes.Buffer
+
+	data := func(s string) {
+		fmt.Fprintf(&stream, "data %d\n%s\n", len(s), s)
+	}
+
+	commit := func(branch string, mark, date int, from, merge int, message string) {
+		fmt.Fprintf(&stream, "commit refs/heads/%s\nmark :%d\n", branch, mark)
+		fmt.Fprintf(&stream, "committer Dev <dev@example.com> %d +0000\n", date)
+		data(message)
+
+		if from > 0 {
+			fmt.Fprintf(&stream, "from :%d\n", from)
+		}
+
+		if merge > 0 {
+			fmt.Fprintf(&stream, "merge :%d\n", merge)
+		}
+
+		fmt.Fprintf(&stream, "M 644 inline %s.txt\n", branch)
+		data(fmt.Sprint(mark))
+	}
+
+	// Marks: 1-100 main, 100+ topics, 1000+ the long branch.
+	commit("main", 1, base, 0, 0, "initial")
+
+	for i := 1; i <= long; i++ {
+		from := 0
+		if i == 1 {
+			from = 1
+		}
+
+		commit("long", 1000+i, base+i*2*topics*60/long, from, 0, fmt.Sprintf("long %d", i))
+	}
+
+	for k := 1; k <= topics; k++ {
+		fork := 2*k - 1
+		date := base + 2*k*60
+
+		for i := 1; i <= 3; i++ {
+			from := 0
+			if i == 1 {
+				from = fork
+			}
+
+			commit(fmt.Sprintf("topic-%d", k), 100*k+i, date-30+i*10, from, 0, fmt.Sprintf("topic %d.%d", k, i))
+		}
+
+		if k%2 == 0 {
+			fmt.Fprintf(&stream, "tag v1.%d.0\nfrom :%d\n", k, 100*k+3)
+			fmt.Fprintf(&stream, "tagger Dev <dev@example.com> %d +0000\n", date)
+			data("release")
+		} else {
+			fmt.Fprintf(&stream, "reset refs/tags/v1.%d.0\nfrom :%d\n\n", k, 100*k+3)
+		}
+
+		commit("main", 2*k, date+20, fork, 100*k+3, fmt.Sprintf("Merge pull request #%d from org/topic-%d", k, k))
+		commit("main", 2*k+1, date+40, 0, 0, fmt.Sprintf("commit %d", k))
+	}
+
+	commit("main", 2*topics+2, base+(2*topics+1)*60, 0, 1000+long, "Merge pull request #99 from org/long")
+
+	dir := tb.TempDir()
+
+	run := func(stdin *bytes.Buffer, args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if stdin != nil {
+			cmd.Stdin = stdin
+		}
+
+		out, err := cmd.CombinedOutput()
+		require.NoError(tb, err, string(out))
+	}
+
+	run(nil, "init", "-q")
+	run(&stream, "fast-import", "--quiet")
+	run(nil, "symbolic-ref", "HEAD", "refs/heads/main")
+	run(nil, "reset", "-q", "--hard")
+
+	return dir
+}
+
+func TestSnapshot_Describe(t *testing.T) {
+	dir := buildTopicRepo(t)
+	ctx := context.Background()
+
+	var calls []string
+
+	gc := git.NewGit(dir)
+	run := gc.GitCmd
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		calls = append(calls, args[2])
+		return run(ctx, env, args...)
+	}
+
+	client := git.NewGit(dir)
+	snapshot := git.NewSnapshot(gc)
+
+	revs := []string{"HEAD", "topic-12", "topic-3", "v1.9.0"}
+
+	for _, rev := range []string{"main~1", "main~4"} {
+		hash, err := client.Clean(client.Run(ctx, "-C", dir, "rev-parse", rev))
+		require.NoError(t, err)
+
+		revs = append(revs, hash)
+	}
+
+	for _, rev := range revs {
+		for _, include := range []string{"v*", "v1.1*", "*"} {
+			expected, err := client.Describe(ctx, include, rev)
+			require.NoError(t, err)
+
+			actual, err := snapshot.Describe(ctx, include, rev)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, actual, "description of %s matching %q", rev, include)
+
+			expectedTag, err := client.AncestorTag(ctx, include, "", rev)
+			require.NoError(t, err)
+
+			actualTag, err := snapshot.AncestorTag(ctx, include, "", rev)
+			require.NoError(t, err)
+
+			assert.Equal(t, expectedTag, actualTag, "ancestor tag of %s matching %q", rev, include)
+		}
+	}
+
+	assert.NotContains(t, calls, "describe")
+}
+
+func TestSnapshot_Fallback(t *testing.T) {
+	var calls []string
+
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		calls = append(calls, args[2])
+
+		if args[2] == "rev-parse" && args[3] == "HEAD" {
+			return "", &git.Error{Args: args, ExitCode: 128, Stderr: "fatal: not a git repository"}
+		}
+
+		return "v1.0.0\n", nil
+	}
+
+	snapshot := git.NewSnapshot(gc)
+
+	tag, err := snapshot.AncestorTag(context.Background(), "v*", "", "main")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", tag)
+
+	exists, err := snapshot.TagExists(context.Background(), "v1.0.0")
+	require.NoError(t, err)
+
+	assert.True(t, exists)
+
+	assert.Equal(t, []string{"rev-parse", "for-each-ref", "log", "describe", "rev-parse"}, calls)
+}
+
+// releaseQueries runs the git queries of a release calculation.
+func releaseQueries(b *testing.B, gc interface {
+	CurrentBranch(ctx context.Context) (string, error)
+	SourceBranch(ctx context.Context, commitHash string) (string, error)
+	LatestTag(ctx context.Context) (string, error)
+	AncestorTag(ctx context.Context, include, exclude, branch string) (string, error)
+	TagExists(ctx context.Context, tag string) (bool, error)
+	Commits(ctx context.Context, from, to string) ([]git.Commit, error)
+}) {
+	ctx := context.Background()
+
+	dest, err := gc.CurrentBranch(ctx)
+	require.NoError(b, err)
+
+	_, _ = gc.SourceBranch(ctx, "HEAD")
+	_, _ = gc.LatestTag(ctx)
+	_, _ = gc.AncestorTag(ctx, "v[0-9]*-pre*", "", dest)
+
+	ancestor, err := gc.AncestorTag(ctx, "v[0-9]*", "v[0-9]*-*", dest)
+	require.NoError(b, err)
+
+	_, _ = gc.TagExists(ctx, ancestor)
+
+	_, err = gc.Commits(ctx, ancestor, "HEAD")
+	require.NoError(b, err)
+}
+
+// countedGit returns a client of dir counting the git commands it runs.
+func countedGit(dir string, calls *int) *git.Client {
+	gc := git.NewGit(dir)
+	run := gc.GitCmd
+
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		*calls++
+		return run(ctx, env, args...)
+	}
+
+	return gc
+}
+
+func BenchmarkClient(b *testing.B) {
+	dir := buildRepo(b, 50000, 10)
+
+	var calls int
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		releaseQueries(b, countedGit(dir, &calls))
+	}
+
+	b.ReportMetric(float64(calls)/float64(b.N), "commands/op")
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	dir := buildRepo(b, 50000, 10)
+
+	var calls int
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		releaseQueries(b, git.NewSnapshot(countedGit(dir, &calls)))
+	}
+
+	b.ReportMetric(float64(calls)/float64(b.N), "commands/op")
+}
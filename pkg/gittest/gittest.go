@@ -0,0 +1,207 @@
+// Package gittest builds temporary git repositories with scripted histories
+// for tests running real git commands.
+package gittest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// startDate is the start of the clock of a repository, 2023-11-14 22:13:20 UTC.
+const startDate = 1700000000
+
+// Repo is a repository in a temporary directory. Every git command moves its
+// clock a minute forward, so commits and tags are dated in the order they are
+// created and git commands walking by date give the same answer on every run.
+type Repo struct {
+	// Dir is the work tree of the repository.
+	Dir string
+
+	tb    TB
+	clock *int64
+}
+
+// TB is the part of testing.TB a Repo uses, so repositories can be built
+// outside of tests too.
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+	TempDir() string
+}
+
+// New returns an empty repository with main checked out. The test is
+// skipped if git is not installed.
+func New(tb testing.TB) *Repo {
+	tb.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		tb.Skip("git not found")
+	}
+
+	return Init(tb, tb.TempDir())
+}
+
+// Init returns an empty repository in dir with main checked out.
+func Init(tb TB, dir string) *Repo {
+	tb.Helper()
+
+	r := &Repo{Dir: dir, tb: tb, clock: new(int64)}
+	r.Git("init", "--quiet", "--initial-branch=main")
+
+	return r
+}
+
+// Git runs a git command in the repository and returns its trimmed output.
+// The test fails if the command fails.
+func (r *Repo) Git(args ...string) string {
+	r.tb.Helper()
+
+	*r.clock++
+	date := fmt.Sprintf("@%d +0000", startDate+*r.clock*60)
+
+	/* #nosec */
+	cmd := exec.Command("git", append([]string{
+		"-c", "user.name=Dev",
+		"-c", "user.email=dev@example.com",
+		"-c", "commit.gpgSign=false",
+		"-c", "tag.gpgSign=false",
+		"-c", "advice.detachedHead=false",
+	}, args...)...)
+	cmd.Dir = r.Dir
+
+	// Ignore the config of the machine running the tests.
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL="+os.DevNull,
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_DATE="+date,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.tb.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// Commit commits a change to a file named after the current branch and
+// returns the commit hash.
+func (r *Repo) Commit(message string) string {
+	r.tb.Helper()
+
+	name := strings.ReplaceAll(r.Git("branch", "--show-current"), "/", "-") + ".txt"
+
+	f, err := os.OpenFile(filepath.Join(r.Dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		r.tb.Fatalf("%s", err)
+	}
+
+	_, err = fmt.Fprintln(f, message)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		r.tb.Fatalf("%s", err)
+	}
+
+	r.Git("add", name)
+	r.Git("commit", "--quiet", "-m", message)
+
+	return r.Head()
+}
+
+// Head returns the hash of the checked out commit.
+func (r *Repo) Head() string {
+	r.tb.Helper()
+
+	return r.Git("rev-parse", "HEAD")
+}
+
+// Branch creates a branch at the checked out commit and checks it out.
+func (r *Repo) Branch(name string) {
+	r.tb.Helper()
+
+	r.Git("checkout", "--quiet", "-b", name)
+}
+
+// Checkout checks out a branch, tag or commit.
+func (r *Repo) Checkout(rev string) {
+	r.tb.Helper()
+
+	r.Git("checkout", "--quiet", rev)
+}
+
+// Merge merges branch into the checked out branch with a merge commit, even
+// if it could fast-forward, and returns the merge commit hash.
+func (r *Repo) Merge(branch, message string) string {
+	r.tb.Helper()
+
+	r.Git("merge", "--quiet", "--no-ff", "-m", message, branch)
+
+	return r.Head()
+}
+
+// MergePR merges branch like GitHub merges pull request number from it.
+func (r *Repo) MergePR(number int, branch string) string {
+	r.tb.Helper()
+
+	return r.Merge(branch, fmt.Sprintf("Merge pull request #%d from org/%s", number, branch))
+}
+
+// MergePRWithTitle merges branch like MergePR, with the pull request title in
+// the message body like GitHub writes it.
+func (r *Repo) MergePRWithTitle(number int, branch, title string) string {
+	r.tb.Helper()
+
+	return r.Merge(branch, fmt.Sprintf("Merge pull request #%d from org/%s\n\n%s", number, branch, title))
+}
+
+// Squash commits the changes of branch onto the checked out branch as a
+// single commit, like a squash merge, and returns its hash.
+func (r *Repo) Squash(branch, message string) string {
+	r.tb.Helper()
+
+	r.Git("merge", "--quiet", "--squash", branch)
+	r.Git("commit", "--quiet", "-m", message)
+
+	return r.Head()
+}
+
+// Tag creates a lightweight tag at the checked out commit.
+func (r *Repo) Tag(name string) {
+	r.tb.Helper()
+
+	r.Git("tag", name)
+}
+
+// AnnotatedTag creates an annotated tag at the checked out commit.
+func (r *Repo) AnnotatedTag(name, message string) {
+	r.tb.Helper()
+
+	r.Git("tag", "-a", "-m", message, name)
+}
+
+// Clone returns a clone of the repository with the last depth commits of
+// the checked out branch, like actions/checkout with fetch-depth, or the
+// whole history if depth is 0.
+func (r *Repo) Clone(depth int) *Repo {
+	r.tb.Helper()
+
+	clone := &Repo{Dir: r.tb.TempDir(), tb: r.tb, clock: r.clock}
+
+	args := []string{"clone", "--quiet"}
+	if depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", depth))
+	}
+
+	// Shallow clones of local paths need the file protocol.
+	r.Git(append(args, "file://"+r.Dir, clone.Dir)...)
+
+	return clone
+}
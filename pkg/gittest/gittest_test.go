@@ -0,0 +1,67 @@
+package gittest_test
+
+import (
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/gittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepo(t *testing.T) {
+	r := gittest.New(t)
+
+	first := r.Commit("initial commit")
+	r.AnnotatedTag("v1.0.0", "release v1.0.0")
+
+	r.Branch("feature/some")
+	r.Commit("add some")
+	r.Checkout("main")
+	merge := r.MergePR(1, "feature/some")
+	r.Tag("v1.1.0")
+
+	r.Branch("bugfix/other")
+	r.Commit("fix other")
+	r.Checkout("main")
+	squash := r.Squash("bugfix/other", "Fix other (#2)")
+
+	assert.Equal(t, "main", r.Git("branch", "--show-current"))
+	assert.Equal(t, squash+"\n"+merge, r.Git("rev-list", "--first-parent", first+"..main"))
+	assert.Equal(t, "Merge pull request #1 from org/feature/some", r.Git("log", "-1", "--format=%s", merge))
+
+	r.Branch("docs/other")
+	r.Commit("document other")
+	r.Checkout("main")
+	titled := r.MergePRWithTitle(3, "docs/other", "Document other [skip release]")
+
+	assert.Equal(t, "Merge pull request #3 from org/docs/other\n\nDocument other [skip release]",
+		r.Git("log", "-1", "--format=%B", titled))
+	assert.Equal(t, "v1.0.0\nv1.1.0", r.Git("tag", "--merged", "main"))
+	assert.Equal(t, "tag", r.Git("cat-file", "-t", "v1.0.0"))
+
+	// Squash commits have a single parent.
+	assert.Equal(t, merge, r.Git("rev-parse", squash+"^@"))
+
+	// Commits are dated in creation order.
+	assert.Less(t, r.Git("log", "-1", "--format=%ct", first), r.Git("log", "-1", "--format=%ct", merge))
+	assert.Less(t, r.Git("log", "-1", "--format=%ct", merge), r.Git("log", "-1", "--format=%ct", squash))
+}
+
+func TestRepo_Clone(t *testing.T) {
+	r := gittest.New(t)
+	r.Commit("initial commit")
+	r.Tag("v1.0.0")
+	r.Commit("second commit")
+	head := r.Commit("third commit")
+
+	shallow := r.Clone(1)
+
+	assert.Equal(t, head, shallow.Head())
+	assert.Equal(t, "true", shallow.Git("rev-parse", "--is-shallow-repository"))
+	assert.Empty(t, shallow.Git("tag"))
+
+	full := r.Clone(0)
+
+	assert.Equal(t, "false", full.Git("rev-parse", "--is-shallow-repository"))
+	assert.Equal(t, "v1.0.0", full.Git("tag"))
+}
@@ -0,0 +1,191 @@
+package gomod
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nolint: gochecknoglobals
+var (
+	moduleRegex      = regexp.MustCompile(`(?m)^\s*module\s+("[^"]+"|\S+)`)
+	majorSuffixRegex = regexp.MustCompile(`^(.+)/v([0-9]+)$`)
+	gopkgInRegex     = regexp.MustCompile(`^(gopkg\.in/.+)\.v([0-9]+)(-unstable)?$`)
+)
+
+// ModulePath returns the module path declared in the given go.mod content.
+func ModulePath(gomod []byte) (string, error) {
+	match := moduleRegex.FindSubmatch(gomod)
+	if match == nil {
+		return "", errors.New("no module directive found")
+	}
+
+	path := string(match[1])
+
+	if strings.HasPrefix(path, `"`) {
+		unquoted, err := strconv.Unquote(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid module path %s: %s", path, err)
+		}
+
+		path = unquoted
+	}
+
+	return path, nil
+}
+
+// Major returns the major version declared by the module path suffix. Paths
+// without a suffix declare v0 or v1, reported as 1.
+func Major(path string) uint64 {
+	if match := gopkgInRegex.FindStringSubmatch(path); match != nil {
+		major, _ := strconv.ParseUint(match[2], 10, 64)
+		return major
+	}
+
+	if match := majorSuffixRegex.FindStringSubmatch(path); match != nil {
+		major, _ := strconv.ParseUint(match[2], 10, 64)
+		if major >= 2 {
+			return major
+		}
+	}
+
+	return 1
+}
+
+// PathForMajor returns the module path for the given major version.
+func PathForMajor(path string, major uint64) string {
+	if match := gopkgInRegex.FindStringSubmatch(path); match != nil {
+		return fmt.Sprintf("%s.v%d", match[1], major)
+	}
+
+	if match := majorSuffixRegex.FindStringSubmatch(path); match != nil {
+		if n, _ := strconv.ParseUint(match[2], 10, 64); n >= 2 {
+			path = match[1]
+		}
+	}
+
+	if major < 2 {
+		return path
+	}
+
+	return fmt.Sprintf("%s/v%d", path, major)
+}
+
+// Validate checks that the module path matches the given major version.
+func Validate(path string, major uint64) error {
+	declared := Major(path)
+
+	if major < 2 && declared == 1 || major == declared {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"module path %q declares major version v%d but version is v%d, module path must be %q",
+		path, declared, major, PathForMajor(path, major))
+}
+
+// Rewrite changes the module path declared in dir/go.mod and every import of
+// it in the Go files under dir, except vendor, testdata and nested modules,
+// which import the module as a dependency.
+func Rewrite(dir, oldPath, newPath string) error {
+	gomodPath := filepath.Join(dir, "go.mod")
+
+	gomod, err := os.ReadFile(gomodPath) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to read go.mod: %s", err)
+	}
+
+	loc := moduleRegex.FindSubmatchIndex(gomod)
+	if loc == nil {
+		return errors.New("no module directive found")
+	}
+
+	gomod = append(append(append([]byte{}, gomod[:loc[2]]...), newPath...), gomod[loc[3]:]...)
+
+	if err := os.WriteFile(gomodPath, gomod, 0600); err != nil {
+		return fmt.Errorf("failed to write go.mod: %s", err)
+	}
+
+	return filepath.WalkDir(dir, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if fp == dir {
+				return nil
+			}
+
+			if d.Name() == "vendor" || d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			if _, err := os.Stat(filepath.Join(fp, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(fp, ".go") {
+			return nil
+		}
+
+		return rewriteImports(fp, oldPath, newPath)
+	})
+}
+
+func rewriteImports(fp, oldPath, newPath string) error {
+	src, err := os.ReadFile(fp) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", fp, err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), fp, src, parser.ImportsOnly)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %s", fp, err)
+	}
+
+	type replacement struct {
+		start, end int
+		path       string
+	}
+
+	var replacements []replacement
+
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || (path != oldPath && !strings.HasPrefix(path, oldPath+"/")) {
+			continue
+		}
+
+		start := int(spec.Path.Pos()) - 1
+
+		replacements = append(replacements, replacement{
+			start: start,
+			end:   start + len(spec.Path.Value),
+			path:  strconv.Quote(newPath + strings.TrimPrefix(path, oldPath)),
+		})
+	}
+
+	if len(replacements) == 0 {
+		return nil
+	}
+
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start > replacements[j].start })
+
+	for _, r := range replacements {
+		src = bytes.Join([][]byte{src[:r.start], []byte(r.path), src[r.end:]}, nil)
+	}
+
+	return os.WriteFile(fp, src, 0600)
+}
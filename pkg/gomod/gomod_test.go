@@ -0,0 +1,157 @@
+package gomod_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/gomod"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModulePath(t *testing.T) {
+	tests := map[string]struct {
+		GoMod    string
+		Expected string
+	}{
+		"plain": {
+			GoMod:    "// comment\nmodule example.com/x\n\ngo 1.19\n",
+			Expected: "example.com/x",
+		},
+		"quoted": {
+			GoMod:    "module \"example.com/x/v2\"\n",
+			Expected: "example.com/x/v2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, err := gomod.ModulePath([]byte(test.GoMod))
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, path)
+		})
+	}
+}
+
+func TestModulePath_NotFound(t *testing.T) {
+	_, err := gomod.ModulePath([]byte("go 1.19\n"))
+
+	assert.EqualError(t, err, "no module directive found")
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		Path     string
+		Major    uint64
+		Expected string
+	}{
+		"v0 without suffix": {
+			Path:  "example.com/x",
+			Major: 0,
+		},
+		"v1 without suffix": {
+			Path:  "example.com/x",
+			Major: 1,
+		},
+		"v2 with suffix": {
+			Path:  "example.com/x/v2",
+			Major: 2,
+		},
+		"gopkg.in": {
+			Path:  "gopkg.in/yaml.v3",
+			Major: 3,
+		},
+		"v2 without suffix": {
+			Path:  "example.com/x",
+			Major: 2,
+			Expected: "module path \"example.com/x\" declares major version v1 but version is v2," +
+				" module path must be \"example.com/x/v2\"",
+		},
+		"v1 with suffix": {
+			Path:  "example.com/x/v3",
+			Major: 1,
+			Expected: "module path \"example.com/x/v3\" declares major version v3 but version is v1," +
+				" module path must be \"example.com/x\"",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := gomod.Validate(test.Path, test.Major)
+
+			if test.Expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.Expected)
+			}
+		})
+	}
+}
+
+func TestPathForMajor(t *testing.T) {
+	assert.Equal(t, "example.com/x/v2", gomod.PathForMajor("example.com/x", 2))
+	assert.Equal(t, "example.com/x/v3", gomod.PathForMajor("example.com/x/v2", 3))
+	assert.Equal(t, "example.com/x", gomod.PathForMajor("example.com/x/v2", 1))
+	assert.Equal(t, "gopkg.in/yaml.v4", gomod.PathForMajor("gopkg.in/yaml.v3", 4))
+}
+
+func TestRewrite(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"go.mod": "module example.com/x\n\ngo 1.19\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/x/pkg/lib"
+	other "example.com/xyz"
+)
+
+func main() { fmt.Println(lib.Name, other.Name, "example.com/x/pkg/lib") }
+`,
+		"pkg/lib/lib.go":          "package lib\n\nimport _ \"example.com/x\"\n\nconst Name = \"lib\"\n",
+		"vendor/example.com/v.go": "package v\n\nimport _ \"example.com/x\"\n",
+		"tools/go.mod":            "module example.com/x/tools\n\nrequire example.com/x v1.4.0\n",
+		"tools/gen.go":            "package tools\n\nimport _ \"example.com/x/pkg/lib\"\n",
+	}
+
+	for name, content := range files {
+		fp := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0700))
+		require.NoError(t, os.WriteFile(fp, []byte(content), 0600))
+	}
+
+	err := gomod.Rewrite(dir, "example.com/x", "example.com/x/v2")
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"go.mod": "module example.com/x/v2\n\ngo 1.19\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/x/v2/pkg/lib"
+	other "example.com/xyz"
+)
+
+func main() { fmt.Println(lib.Name, other.Name, "example.com/x/pkg/lib") }
+`,
+		"pkg/lib/lib.go":          "package lib\n\nimport _ \"example.com/x/v2\"\n\nconst Name = \"lib\"\n",
+		"vendor/example.com/v.go": "package v\n\nimport _ \"example.com/x\"\n",
+		"tools/go.mod":            "module example.com/x/tools\n\nrequire example.com/x v1.4.0\n",
+		"tools/gen.go":            "package tools\n\nimport _ \"example.com/x/pkg/lib\"\n",
+	}
+
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+
+		assert.Equal(t, content, string(data), name)
+	}
+}
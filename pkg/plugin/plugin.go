@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// ProtocolVersion is the version of the request sent to plugins.
+const ProtocolVersion = 1
+
+// nolint: gochecknoglobals
+var validBumps = []string{"", "none", "major", "minor", "patch"}
+
+type (
+	// Request is the context written as JSON to the plugin's stdin.
+	Request struct {
+		Version      int    `json:"version"`
+		SourceBranch string `json:"source_branch"`
+		DestBranch   string `json:"dest_branch"`
+		MainBranch   string `json:"main_branch"`
+		// PreviousTag is empty if the repository has no tag yet.
+		PreviousTag  string   `json:"previous_tag"`
+		Commits      []Commit `json:"commits"`
+		ChangedFiles []string `json:"changed_files"`
+	}
+
+	// Commit is a commit since the previous tag.
+	Commit struct {
+		Hash    string `json:"hash"`
+		Subject string `json:"subject"`
+	}
+
+	// Response is the reply read as JSON from the plugin's stdout.
+	Response struct {
+		// Bump is one of major, minor, patch or none. Empty defers to the branch rules.
+		Bump   string `json:"bump"`
+		Reason string `json:"reason"`
+	}
+)
+
+// Run runs the plugin command in dir with the request on stdin and returns its
+// response. The command is split on whitespace, e.g. "python3 rules.py", and
+// killed if ctx is done.
+func Run(ctx context.Context, command, dir string, req Request) (Response, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return Response{}, errors.New("empty plugin command")
+	}
+
+	req.Version = ProtocolVersion
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	/* #nosec */
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(input)
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.WithField("args", args).Debug("running plugin")
+
+	err = cmd.Run()
+
+	log.WithField("stdout", stdout.String()).
+		WithField("stderr", stderr.String()).
+		Debug("plugin result")
+
+	if err != nil {
+		return Response{}, fmt.Errorf("plugin %q failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp Response
+
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return Response{}, fmt.Errorf("failed to decode plugin response: %w", err)
+	}
+
+	if !stringInSlice(resp.Bump, validBumps) {
+		return Response{}, fmt.Errorf("invalid plugin bump: %s", resp.Bump)
+	}
+
+	return resp, nil
+}
+
+func stringInSlice(a string, list []string) bool {
+	for _, b := range list {
+		if b == a {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperPlugin is run as the plugin by the other tests.
+func TestHelperPlugin(t *testing.T) {
+	mode := os.Getenv("PLUGIN_HELPER_MODE")
+	if mode == "" {
+		return
+	}
+
+	var req plugin.Request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	switch mode {
+	case "echo":
+		fmt.Printf(`{"bump": "major", "reason": "%d %s %s %s %s %d %s"}`,
+			req.Version, req.SourceBranch, req.DestBranch, req.MainBranch, req.PreviousTag,
+			len(req.Commits), req.ChangedFiles)
+	case "invalid":
+		fmt.Print(`{"bump": "huge"}`)
+	case "fail":
+		fmt.Fprint(os.Stderr, "rule not met")
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+func runHelper(t *testing.T, mode string) (plugin.Response, error) {
+	t.Setenv("PLUGIN_HELPER_MODE", mode)
+
+	return plugin.Run(context.Background(), os.Args[0]+" -test.run=TestHelperPlugin", ".", plugin.Request{
+		SourceBranch: "feature/some",
+		DestBranch:   "main",
+		MainBranch:   "main",
+		PreviousTag:  "v1.2.0",
+		Commits:      []plugin.Commit{{Hash: "81918ffc", Subject: "feat: add parser"}},
+		ChangedFiles: []string{"api/openapi.yaml"},
+	})
+}
+
+func TestRun(t *testing.T) {
+	resp, err := runHelper(t, "echo")
+	require.NoError(t, err)
+
+	assert.Equal(t, plugin.Response{
+		Bump:   "major",
+		Reason: "1 feature/some main main v1.2.0 1 [api/openapi.yaml]",
+	}, resp)
+}
+
+func TestRun_InvalidBump(t *testing.T) {
+	_, err := runHelper(t, "invalid")
+	assert.EqualError(t, err, "invalid plugin bump: huge")
+}
+
+func TestRun_Failure(t *testing.T) {
+	_, err := runHelper(t, "fail")
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "exit status 1: rule not met")
+}
+
+func TestRun_EmptyCommand(t *testing.T) {
+	_, err := plugin.Run(context.Background(), " ", ".", plugin.Request{})
+	assert.EqualError(t, err, "empty plugin command")
+}
@@ -0,0 +1,349 @@
+// Package scenario runs versioning scenarios described in YAML: a git
+// history, the action inputs and environment, and the expected outputs.
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/snapfi/semver-action/cmd/generate"
+	"github.com/snapfi/semver-action/internal/versioning"
+	"github.com/snapfi/semver-action/pkg/gittest"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Scenario is a git history with the action inputs and the outputs
+	// expected when the action runs on its last commit.
+	Scenario struct {
+		Description string `yaml:"description"`
+		History     []Step `yaml:"history"`
+		// FetchDepth runs the action on a shallow clone with this many
+		// commits, like actions/checkout with fetch-depth.
+		FetchDepth int `yaml:"fetch_depth"`
+		// Inputs are the action inputs, e.g. bump or prefix.
+		Inputs map[string]string `yaml:"inputs"`
+		// Env holds extra environment variables. GITHUB_SHA defaults to the
+		// last commit.
+		Env map[string]string `yaml:"env"`
+		// Expected holds the expected value of outputs, keyed by output name.
+		// Outputs not listed aren't checked.
+		Expected map[string]string `yaml:"expected"`
+		// ExpectedError is a part of the expected error message, if the
+		// action must fail.
+		ExpectedError string `yaml:"expected_error"`
+	}
+
+	// Step is a change to the repository. Exactly one of its actions is set.
+	Step struct {
+		// Commit commits a change to the checked out branch with this message.
+		Commit string `yaml:"commit"`
+		// Branch creates a branch at the checked out commit and checks it out.
+		Branch string `yaml:"branch"`
+		// Checkout checks out a branch, tag or commit.
+		Checkout string `yaml:"checkout"`
+		// Merge merges a branch into the checked out branch with a merge
+		// commit, named like GitHub pull request merges when PR is set.
+		Merge string `yaml:"merge"`
+		PR    int    `yaml:"pr"`
+		// Squash commits the changes of a branch as a single commit.
+		Squash string `yaml:"squash"`
+		// Tag creates a lightweight tag at the checked out commit.
+		Tag string `yaml:"tag"`
+		// AnnotatedTag creates an annotated tag at the checked out commit.
+		AnnotatedTag string `yaml:"annotated_tag"`
+		// Message is the message of merges, squashes and annotated tags. For
+		// pull request merges, it is the title below the merge header.
+		Message string `yaml:"message"`
+	}
+
+	// Report is the outcome of a scenario.
+	Report struct {
+		// Outputs are the outputs of the action, empty if it failed.
+		Outputs map[string]string
+		Err     error
+		// Diffs lists the outputs, or the error, not matching the expected ones.
+		Diffs []Diff
+	}
+
+	// Diff is an output that doesn't match the expected value.
+	Diff struct {
+		Name     string
+		Expected string
+		Actual   string
+	}
+
+	// builder reports the failures of the git commands building the
+	// repository as a buildError panic, recovered by build.
+	builder struct {
+		root string
+	}
+
+	buildError struct {
+		err error
+	}
+)
+
+// Passed returns true if every output matched the expected value.
+func (r Report) Passed() bool {
+	return len(r.Diffs) == 0
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%s: expected %q, got %q", d.Name, d.Expected, d.Actual)
+}
+
+// Load reads and validates the scenario in the file at path.
+func Load(path string) (Scenario, error) {
+	data, err := os.ReadFile(path) // nolint:gosec
+	if err != nil {
+		return Scenario{}, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var s Scenario
+	if err := decoder.Decode(&s); err != nil {
+		return Scenario{}, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+
+	if err := s.Validate(); err != nil {
+		return Scenario{}, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// Validate returns an error if the scenario can't run.
+func (s Scenario) Validate() error {
+	if len(s.History) == 0 {
+		return errors.New("empty history")
+	}
+
+	for i, step := range s.History {
+		var actions int
+
+		for _, action := range []string{
+			step.Commit, step.Branch, step.Checkout, step.Merge, step.Squash, step.Tag, step.AnnotatedTag,
+		} {
+			if action != "" {
+				actions++
+			}
+		}
+
+		if actions != 1 {
+			return fmt.Errorf("step %d must have exactly one action, has %d", i+1, actions)
+		}
+
+		if step.PR != 0 && step.Merge == "" {
+			return fmt.Errorf("step %d sets pr without merge", i+1)
+		}
+	}
+
+	if s.FetchDepth < 0 {
+		return fmt.Errorf("invalid fetch_depth: %d", s.FetchDepth)
+	}
+
+	return nil
+}
+
+// Run builds the history of the scenario in a temporary directory, runs the
+// action on it and compares its outputs with the expected ones. The
+// environment variables of the process are replaced while the action runs,
+// so scenarios can't run concurrently.
+func Run(s Scenario) (Report, error) {
+	root, err := os.MkdirTemp("", "semver-scenario")
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to create scenario directory: %w", err)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(root)
+	}()
+
+	dir, head, err := build(s, root)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to build history: %w", err)
+	}
+
+	env := map[string]string{
+		"GITHUB_SHA":     head,
+		"INPUT_REPO_DIR": dir,
+	}
+
+	for name, value := range s.Inputs {
+		env["INPUT_"+strings.ToUpper(strings.ReplaceAll(name, " ", "_"))] = value
+	}
+
+	for name, value := range s.Env {
+		env[name] = value
+	}
+
+	restore := setEnv(env)
+	result, runErr := generate.Run()
+	restore()
+
+	report := Report{Err: runErr}
+
+	if runErr == nil {
+		report.Outputs = outputs(result)
+	}
+
+	names := make([]string, 0, len(s.Expected))
+	for name := range s.Expected {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if actual := report.Outputs[name]; actual != s.Expected[name] {
+			report.Diffs = append(report.Diffs, Diff{Name: name, Expected: s.Expected[name], Actual: actual})
+		}
+	}
+
+	var message string
+	if runErr != nil {
+		message = runErr.Error()
+	}
+
+	if (s.ExpectedError == "" && runErr != nil) ||
+		(s.ExpectedError != "" && (runErr == nil || !strings.Contains(message, s.ExpectedError))) {
+		report.Diffs = append(report.Diffs, Diff{Name: "error", Expected: s.ExpectedError, Actual: message})
+	}
+
+	return report, nil
+}
+
+// build creates the history of s in a directory under root and returns the
+// directory with its checked out commit.
+func build(s Scenario, root string) (dir, head string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			failure, ok := r.(buildError)
+			if !ok {
+				panic(r)
+			}
+
+			err = failure.err
+		}
+	}()
+
+	tb := builder{root: root}
+
+	repo := gittest.Init(tb, tb.TempDir())
+
+	for _, step := range s.History {
+		apply(repo, step)
+	}
+
+	if s.FetchDepth > 0 {
+		repo = repo.Clone(s.FetchDepth)
+	}
+
+	return repo.Dir, repo.Head(), nil
+}
+
+// apply applies the action of step to repo.
+func apply(repo *gittest.Repo, step Step) {
+	switch {
+	case step.Commit != "":
+		repo.Commit(step.Commit)
+	case step.Branch != "":
+		repo.Branch(step.Branch)
+	case step.Checkout != "":
+		repo.Checkout(step.Checkout)
+	case step.Merge != "" && step.PR != 0 && step.Message != "":
+		repo.MergePRWithTitle(step.PR, step.Merge, step.Message)
+	case step.Merge != "" && step.PR != 0:
+		repo.MergePR(step.PR, step.Merge)
+	case step.Merge != "":
+		repo.Merge(step.Merge, defaultString(step.Message, fmt.Sprintf("Merge branch '%s'", step.Merge)))
+	case step.Squash != "":
+		repo.Squash(step.Squash, defaultString(step.Message, step.Squash))
+	case step.Tag != "":
+		repo.Tag(step.Tag)
+	case step.AnnotatedTag != "":
+		repo.AnnotatedTag(step.AnnotatedTag, defaultString(step.Message, step.AnnotatedTag))
+	}
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// outputs returns the action outputs of result, keyed by output name.
+func outputs(result versioning.Result) map[string]string {
+	values := map[string]string{
+		"previous_tag":   result.PreviousTag,
+		"ancestor_tag":   result.AncestorTag,
+		"semver_tag":     result.SemverTag,
+		"is_prerelease":  strconv.FormatBool(result.IsPrerelease),
+		"skipped":        strconv.FormatBool(result.Skipped),
+		"should_release": strconv.FormatBool(result.ShouldRelease()),
+		"bump_type":      result.BumpType,
+		"reason":         result.Reason,
+		"explain":        strings.Join(result.Explain, "\n"),
+	}
+
+	for name, value := range result.Formats {
+		values[name] = value
+	}
+
+	return values
+}
+
+// setEnv replaces the action inputs and GitHub variables of the process
+// with env and returns a function restoring them.
+func setEnv(env map[string]string) func() {
+	saved := make(map[string]string)
+
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+
+		if _, ok := env[name]; ok || strings.HasPrefix(name, "INPUT_") || strings.HasPrefix(name, "GITHUB_") {
+			saved[name] = value
+			_ = os.Unsetenv(name)
+		}
+	}
+
+	for name, value := range env {
+		_ = os.Setenv(name, value)
+	}
+
+	return func() {
+		for name := range env {
+			_ = os.Unsetenv(name)
+		}
+
+		for name, value := range saved {
+			_ = os.Setenv(name, value)
+		}
+	}
+}
+
+func (builder) Helper() {}
+
+func (builder) Fatalf(format string, args ...interface{}) {
+	panic(buildError{err: fmt.Errorf(format, args...)})
+}
+
+func (b builder) TempDir() string {
+	dir, err := os.MkdirTemp(b.root, "repo")
+	if err != nil {
+		b.Fatalf("%s", err)
+	}
+
+	return dir
+}
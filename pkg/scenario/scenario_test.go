@@ -0,0 +1,109 @@
+package scenario_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/scenario"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarios(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	files, err := filepath.Glob("../../testdata/scenarios/*.yaml")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		file := file
+
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			s, err := scenario.Load(file)
+			require.NoError(t, err)
+
+			report, err := scenario.Run(s)
+			require.NoError(t, err)
+
+			assert.True(t, report.Passed(), "%s\n%v", s.Description, report.Diffs)
+		})
+	}
+}
+
+func TestRun_Diffs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	t.Setenv("INPUT_PREFIX", "release-")
+
+	report, err := scenario.Run(scenario.Scenario{
+		History: []scenario.Step{
+			{Commit: "initial commit"},
+			{Tag: "v1.0.0"},
+			{Branch: "feature/login"},
+			{Commit: "add login"},
+			{Checkout: "main"},
+			{Merge: "feature/login", PR: 1},
+		},
+		Inputs:        map[string]string{"prefix": "v"},
+		Expected:      map[string]string{"semver_tag": "v2.0.0", "previous_tag": "v1.0.0"},
+		ExpectedError: "boom",
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, report.Err)
+	assert.Equal(t, "v1.1.0", report.Outputs["semver_tag"])
+	assert.False(t, report.Passed())
+	assert.Equal(t, []scenario.Diff{
+		{Name: "semver_tag", Expected: "v2.0.0", Actual: "v1.1.0"},
+		{Name: "error", Expected: "boom"},
+	}, report.Diffs)
+
+	// The environment of the process is restored.
+	assert.Equal(t, "release-", os.Getenv("INPUT_PREFIX"))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		Content string
+		Err     string
+	}{
+		"two actions": {
+			Content: "history:\n  - commit: initial commit\n    tag: v1.0.0\n",
+			Err:     "step 1 must have exactly one action, has 2",
+		},
+		"unknown field": {
+			Content: "history:\n  - commit: initial commit\nexpect:\n  semver_tag: v1.0.0\n",
+			Err:     "field expect not found",
+		},
+		"empty history": {
+			Content: "inputs:\n  bump: major\n",
+			Err:     "empty history",
+		},
+		"pr without merge": {
+			Content: "history:\n  - commit: initial commit\n    pr: 1\n",
+			Err:     "step 1 sets pr without merge",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "scenario.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tc.Content), 0600))
+
+			_, err := scenario.Load(file)
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), tc.Err)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Package semver calculates the next version of a git repository, for
+// embedding the action in other programs.
+package semver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/snapfi/semver-action/internal/versioning"
+	"github.com/snapfi/semver-action/pkg/conventional"
+	"github.com/snapfi/semver-action/pkg/git"
+
+	blang "github.com/blang/semver/v4"
+)
+
+// Errors returned by Next, failures of git commands are *git.Error or *git.TimeoutError.
+// nolint: gochecknoglobals
+var (
+	ErrNotRepository       = versioning.ErrNotRepository
+	ErrInvalidBumpStrategy = versioning.ErrInvalidBumpStrategy
+	ErrNoPrerelease        = versioning.ErrNoPrerelease
+	ErrVersionExists       = versioning.ErrVersionExists
+	ErrAlreadyGraduated    = versioning.ErrAlreadyGraduated
+)
+
+type (
+	// Repository is the git repository the version is calculated for.
+	// *git.Client and *git.Snapshot implement it.
+	Repository = versioning.Repository
+
+	// Options configures the calculation. The zero value of a field means its
+	// default, which matches the default of the action input of the same name.
+	Options struct {
+		// Repository answers the git queries instead of the git repository in
+		// Dir. The git options and retractions recorded in Dir don't apply to it.
+		Repository Repository
+		// Dir is the git repository directory, defaults to ".".
+		Dir string
+		// GitTimeout limits the duration of each git command, if set.
+		GitTimeout time.Duration
+		// GitSnapshot answers the git queries from refs and history loaded in
+		// bulk, which saves commands on big repositories.
+		GitSnapshot bool
+		// Commit is the merge commit, defaults to HEAD.
+		Commit             string
+		Bump               string
+		Scheme             string
+		CalVerFormat       string
+		BaseVersion        string
+		Prefix             string
+		PrereleaseID       string
+		PrereleaseChannels []string
+		PromoteTo          string
+		ForcePrerelease    bool
+		InitialDevelopment bool
+		ChangeDetectors    []string
+		ChangeDetectorMode string
+		StrategyPlugin     string
+		GoModule           bool
+		GoModuleRewrite    bool
+		MainBranch         string
+		// Convention is the commit convention of the conventional change
+		// detector, defaults to conventional.Default().
+		Convention *conventional.Convention
+		// Retracted lists the tags of pulled versions, skipped as latest and
+		// ancestor tags. Retractions recorded in Dir are added to them.
+		Retracted []string
+	}
+
+	// Result is the calculated version.
+	Result struct {
+		// Tag is the new version tag, empty if the merge doesn't release.
+		Tag string
+		// PreviousTag is the tag the version is calculated from, empty if there is no tag yet.
+		PreviousTag  string
+		AncestorTag  string
+		IsPrerelease bool
+		// Skipped is true if a marker in the commit message skipped the release.
+		Skipped bool
+		// ShouldRelease is true if Tag is set.
+		ShouldRelease bool
+		// BumpType is the highest part of the version changed from
+		// PreviousTag: major, minor, patch, prerelease or finalize. It is none
+		// if nothing is released.
+		BumpType string
+		// Reason tells why nothing is released, empty if a version is.
+		Reason string
+		// Source is the merged branch of a released version, empty if it
+		// wasn't merged from one.
+		Source string
+		// Rule is what picked the bump of a released version, e.g.
+		// feature/ into main or bump input major.
+		Rule string
+		// Explain contains details on how the version was calculated.
+		Explain []string
+		// Formats contains the version rendered for each ecosystem, keyed by output name.
+		Formats map[string]string
+	}
+
+	// OptionsError is returned if an option is invalid.
+	OptionsError struct {
+		Option string
+		Value  string
+	}
+)
+
+func (e *OptionsError) Error() string {
+	return fmt.Sprintf("invalid %s option: %q", e.Option, e.Value)
+}
+
+// Next calculates the next version of the repository. Git commands and
+// strategy plugins are killed once ctx is done.
+func Next(ctx context.Context, opts Options) (Result, error) {
+	params, err := opts.params()
+	if err != nil {
+		return Result{}, err
+	}
+
+	repo := opts.Repository
+	if repo == nil {
+		gc := git.NewGit(params.RepoDir)
+		gc.Timeout = opts.GitTimeout
+
+		if err := versioning.Retract(ctx, &params, gc); err != nil {
+			return Result{}, err
+		}
+
+		repo = gc
+		if opts.GitSnapshot {
+			repo = git.NewSnapshot(gc)
+		}
+	}
+
+	result, err := versioning.Calculate(ctx, params, repo)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Tag:           result.SemverTag,
+		PreviousTag:   result.PreviousTag,
+		AncestorTag:   result.AncestorTag,
+		IsPrerelease:  result.IsPrerelease,
+		Skipped:       result.Skipped,
+		ShouldRelease: result.ShouldRelease(),
+		BumpType:      result.BumpType,
+		Reason:        result.Reason,
+		Source:        result.Source,
+		Rule:          result.Rule,
+		Explain:       result.Explain,
+		Formats:       result.Formats,
+	}, nil
+}
+
+// params validates the options and applies the defaults.
+func (o Options) params() (versioning.Params, error) {
+	p := versioning.Params{
+		CommitSha:          withDefault(o.Commit, "HEAD"),
+		RepoDir:            withDefault(o.Dir, "."),
+		Bump:               withDefault(o.Bump, "auto"),
+		Scheme:             withDefault(o.Scheme, "semver"),
+		CalVerFormat:       withDefault(o.CalVerFormat, "YYYY.MM.MICRO"),
+		Prefix:             withDefault(o.Prefix, "v"),
+		PrereleaseID:       withDefault(o.PrereleaseID, "pre"),
+		PrereleaseChannels: o.PrereleaseChannels,
+		PromoteTo:          o.PromoteTo,
+		ForcePrerelease:    o.ForcePrerelease,
+		InitialDevelopment: o.InitialDevelopment,
+		ChangeDetectors:    o.ChangeDetectors,
+		ChangeDetectorMode: withDefault(o.ChangeDetectorMode, "floor"),
+		Convention:         conventional.Default(),
+		StrategyPlugin:     o.StrategyPlugin,
+		GoModule:           o.GoModule,
+		GoModuleRewrite:    o.GoModuleRewrite,
+		BranchName:         withDefault(o.MainBranch, "main"),
+	}
+
+	if err := p.Validate(); err != nil {
+		var paramErr *versioning.ParamError
+		if errors.As(err, &paramErr) {
+			return versioning.Params{}, &OptionsError{Option: strings.ReplaceAll(paramErr.Param, "_", " "), Value: paramErr.Value}
+		}
+
+		return versioning.Params{}, err
+	}
+
+	if o.Convention != nil {
+		p.Convention = *o.Convention
+	}
+
+	if len(o.Retracted) > 0 {
+		p.Retracted = make(map[string]string, len(o.Retracted))
+
+		for _, tag := range o.Retracted {
+			if !strings.HasPrefix(tag, p.Prefix) {
+				tag = p.Prefix + tag
+			}
+
+			p.Retracted[tag] = "listed in the retracted option"
+		}
+	}
+
+	if o.BaseVersion != "" {
+		parsed, err := blang.Parse(strings.TrimPrefix(o.BaseVersion, p.Prefix))
+		if err != nil {
+			return versioning.Params{}, &OptionsError{Option: "base version", Value: o.BaseVersion}
+		}
+
+		p.BaseVersion = &parsed
+	}
+
+	return p, nil
+}
+
+func withDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+
+	return value
+}
@@ -0,0 +1,200 @@
+package semver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/snapfi/semver-action/pkg/git"
+	"github.com/snapfi/semver-action/pkg/gittest"
+	"github.com/snapfi/semver-action/pkg/semver"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nolint: gochecknoglobals
+var (
+	_ semver.Repository = (*git.Client)(nil)
+	_ semver.Repository = (*git.Snapshot)(nil)
+)
+
+// fakeRepo is a repository on main with a single tag and a merged source branch.
+type fakeRepo struct {
+	tag    string
+	source string
+}
+
+func (r fakeRepo) CurrentBranch(context.Context) (string, error) { return "main", nil }
+func (r fakeRepo) IsRepo(context.Context) (bool, error)          { return true, nil }
+func (r fakeRepo) MakeSafe(context.Context) error                { return nil }
+func (r fakeRepo) LatestTag(context.Context) (string, error)     { return r.tag, nil }
+
+func (r fakeRepo) AncestorTag(context.Context, string, string, string) (string, error) {
+	return r.tag, nil
+}
+
+func (r fakeRepo) Describe(context.Context, string, string) (git.Description, error) {
+	return git.Description{Tag: r.tag, Distance: 1, Hash: "81918ff"}, nil
+}
+
+func (r fakeRepo) SourceBranch(context.Context, string) (string, error) { return r.source, nil }
+
+func (r fakeRepo) CommitMessage(context.Context, string) (string, error) {
+	return "Merge pull request #1 from org/" + r.source, nil
+}
+
+func (r fakeRepo) TagExists(_ context.Context, tag string) (bool, error)    { return tag == r.tag, nil }
+func (r fakeRepo) IsAncestor(context.Context, string, string) (bool, error) { return true, nil }
+
+func (r fakeRepo) Files(context.Context, string, func(path string) bool) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func (r fakeRepo) Commits(context.Context, string, string) ([]git.Commit, error)   { return nil, nil }
+func (r fakeRepo) Messages(context.Context, string, string) ([]git.Message, error) { return nil, nil }
+func (r fakeRepo) ChangedFiles(context.Context, string, string) ([]string, error)  { return nil, nil }
+
+// mergedRepo creates a repository on main with a single tag and source
+// merged by a pull request.
+func mergedRepo(t *testing.T, source string) *gittest.Repo {
+	t.Helper()
+
+	r := gittest.New(t)
+	r.Commit("initial commit")
+	r.Tag("v1.2.3")
+
+	r.Branch(source)
+	r.Commit("change")
+	r.Checkout("main")
+	r.MergePR(1, source)
+
+	return r
+}
+
+func TestNext(t *testing.T) {
+	tests := map[string]struct {
+		Source   string
+		Options  semver.Options
+		Expected semver.Result
+	}{
+		"defaults": {
+			Source: "feature/some",
+			Expected: semver.Result{
+				Tag:           "v1.3.0",
+				PreviousTag:   "v1.2.3",
+				AncestorTag:   "v1.2.3",
+				ShouldRelease: true,
+				BumpType:      "minor",
+				Source:        "feature/some",
+				Rule:          "feature/ into main",
+				Formats: map[string]string{
+					"version_debian":         "1.3.0",
+					"version_maven":          "1.3.0",
+					"version_maven_snapshot": "1.3.0",
+					"version_nuget":          "1.3.0",
+					"version_pep440":         "1.3.0",
+				},
+			},
+		},
+		"no release": {
+			Source: "docs/some",
+			Expected: semver.Result{
+				PreviousTag: "v1.2.3",
+				BumpType:    "none",
+				Reason:      "merging docs/some into main doesn't release",
+			},
+		},
+		"forced bump from base version": {
+			Source: "feature/some",
+			Options: semver.Options{
+				Bump:        "major",
+				BaseVersion: "v3.0.0",
+			},
+			Expected: semver.Result{
+				Tag:           "v4.0.0",
+				PreviousTag:   "v1.2.3",
+				AncestorTag:   "v1.2.3",
+				ShouldRelease: true,
+				BumpType:      "major",
+				Source:        "feature/some",
+				Rule:          "bump input major",
+				Formats: map[string]string{
+					"version_debian":         "4.0.0",
+					"version_maven":          "4.0.0",
+					"version_maven_snapshot": "4.0.0",
+					"version_nuget":          "4.0.0",
+					"version_pep440":         "4.0.0",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			opts := test.Options
+			opts.Dir = mergedRepo(t, test.Source).Dir
+
+			result, err := semver.Next(context.Background(), opts)
+			require.NoError(t, err)
+
+			result.Explain = nil
+
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestNext_Repository(t *testing.T) {
+	result, err := semver.Next(context.Background(), semver.Options{
+		Repository: fakeRepo{tag: "v1.2.3", source: "bugfix/some"},
+		Dir:        t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.2.4", result.Tag)
+	assert.Equal(t, "v1.2.3", result.PreviousTag)
+	assert.Equal(t, "bugfix/ into main", result.Rule)
+}
+
+func TestNext_InvalidOptions(t *testing.T) {
+	tests := map[string]struct {
+		Options semver.Options
+		Error   string
+	}{
+		"bump":                 {semver.Options{Bump: "huge"}, `invalid bump option: "huge"`},
+		"change detector mode": {semver.Options{ChangeDetectorMode: "max"}, `invalid change detector mode option: "max"`},
+		"promote to":           {semver.Options{PromoteTo: "rc"}, `invalid promote to option: "rc"`},
+	}
+
+	for option, test := range tests {
+		test := test
+
+		t.Run(option, func(t *testing.T) {
+			_, err := semver.Next(context.Background(), test.Options)
+
+			var optErr *semver.OptionsError
+			require.True(t, errors.As(err, &optErr))
+
+			assert.Equal(t, option, optErr.Option)
+			assert.EqualError(t, err, test.Error)
+		})
+	}
+}
+
+func TestNext_NotRepository(t *testing.T) {
+	_, err := semver.Next(context.Background(), semver.Options{Dir: t.TempDir()})
+
+	assert.ErrorIs(t, err, semver.ErrNotRepository)
+}
+
+func TestNext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := semver.Next(ctx, semver.Options{Dir: mergedRepo(t, "feature/some").Dir})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
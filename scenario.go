@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/snapfi/semver-action/pkg/scenario"
+
+	"github.com/apex/log"
+)
+
+// testScenario runs the scenario files in args and returns the exit code.
+func testScenario(args []string) int {
+	if len(args) == 0 {
+		log.Error("usage: semver test-scenario file.yaml...")
+
+		return exitInvalidParams
+	}
+
+	code := 0
+
+	for _, file := range args {
+		s, err := scenario.Load(file)
+		if err != nil {
+			log.Errorf("%s", err)
+
+			code = exitInvalidParams
+
+			continue
+		}
+
+		report, err := scenario.Run(s)
+		if err != nil {
+			log.Errorf("%s: %s", file, err)
+
+			code = exitFailure
+
+			continue
+		}
+
+		if report.Passed() {
+			log.Infof("PASS %s", file)
+
+			continue
+		}
+
+		log.Errorf("FAIL %s", file)
+
+		for _, diff := range report.Diffs {
+			log.Errorf("  %s", diff)
+		}
+
+		code = exitFailure
+	}
+
+	return code
+}